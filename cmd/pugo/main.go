@@ -6,6 +6,7 @@ import (
 	"pugo/pkg/cmd"
 	"pugo/pkg/core/constants"
 	"strings"
+	_ "time/tzdata" // site timezone works without system zoneinfo
 
 	"github.com/urfave/cli/v2"
 )
@@ -15,8 +16,10 @@ var (
 	commands []*cli.Command = []*cli.Command{
 		cmd.NewInit(),
 		cmd.NewBuild(),
+		cmd.NewCheck(),
 		cmd.NewCreate(),
 		cmd.NewServer(),
+		cmd.NewHighlight(),
 		{
 			Name:  "version",
 			Usage: "print the version of PuGo",
@@ -39,7 +42,10 @@ func main() {
 		Flags:    cmd.GetGlobalFlags(),
 	}
 	args := movePostfixOptions(os.Args)
-	app.Run(args)
+	if err := app.Run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
 // Function to reorder arguments in "correct" order for urfave/cli
@@ -4,19 +4,23 @@ go 1.18
 
 require (
 	github.com/BurntSushi/toml v1.1.0
+	github.com/alecthomas/chroma/v2 v2.3.0
 	github.com/fsnotify/fsnotify v1.5.4
 	github.com/mholt/archiver/v4 v4.0.0-alpha.6
 	github.com/tdewolff/minify/v2 v2.11.1
+	github.com/tdewolff/parse/v2 v2.5.29
 	github.com/urfave/cli/v2 v2.4.0
 	github.com/yuin/goldmark v1.4.11
 	go.uber.org/atomic v1.7.0
 	go.uber.org/zap v1.21.0
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/andybalholm/brotli v1.0.4 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.1 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
 	github.com/dsnet/compress v0.0.1 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.15.1 // indirect
@@ -24,10 +28,9 @@ require (
 	github.com/nwaples/rardecode/v2 v2.0.0-beta.2 // indirect
 	github.com/pierrec/lz4/v4 v4.1.14 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/tdewolff/parse/v2 v2.5.29 // indirect
 	github.com/therootcompany/xz v1.0.1 // indirect
 	github.com/ulikunitz/xz v0.5.10 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
-	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/text v0.16.0 // indirect
 )
@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"pugo/pkg/core/configs"
+	"pugo/pkg/core/constants"
 	"pugo/pkg/core/generator"
+	"pugo/pkg/core/report"
+	"pugo/pkg/utils/zlog"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
@@ -25,6 +33,47 @@ var (
 			Usage: "compress built files to one archive",
 		},
 	}
+	// renderFlags are flags of rendering contents, used in build and server
+	renderFlags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "future",
+			Usage: "build posts dated in the future",
+		},
+		&cli.StringFlag{
+			Name:  "now",
+			Usage: "build as of the given time, such as '2006-01-02T15:04:05+08:00', to publish scheduled and expire posts, in site timezone if no offset",
+		},
+		&cli.IntFlag{
+			Name:  "jobs",
+			Usage: "number of parallel render workers, default is the number of cpus",
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "ignore the manifest of last build and rebuild all files",
+		},
+		&cli.BoolFlag{
+			Name:  "validate-feed",
+			Usage: "check generated feeds and fail the build if invalid",
+		},
+	}
+	buildFlags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "fail the build on any warning",
+		},
+		&cli.StringFlag{
+			Name:  "report",
+			Usage: "write diagnostics of the build to file in JSON, '-' for stdout",
+		},
+		&cli.BoolFlag{
+			Name:  "check-links",
+			Usage: "check links, anchors and images in generated html, all pages are rebuilt",
+		},
+		&cli.BoolFlag{
+			Name:  "external",
+			Usage: "also request external links in checking links",
+		},
+	}
 )
 
 // NewBuild returns a new cli.Command for the build subcommand.
@@ -33,30 +82,89 @@ func NewBuild() *cli.Command {
 		Name:        "build",
 		Usage:       "build the site",
 		Description: "build the site and generate the static files",
-		Flags:       append(globalFlags, genFlags...),
+		Flags:       append(append(append(globalFlags, genFlags...), renderFlags...), buildFlags...),
 		Aliases:     []string{"gen"},
 		Action: func(c *cli.Context) error {
 
 			initGlobalFlags(c)
 
 			opt := parseCliOption(c)
+			opt.Strict = c.Bool("strict")
+			opt.CheckLinks = c.Bool("check-links")
+			opt.CheckExternal = c.Bool("external")
 
-			generator.Generate(opt)
-
-			return nil
+			return buildWithReport(opt, c.String("report"))
 		},
 	}
 	return cmd
 }
 
+// buildWithReport builds the site and writes the report to file if file is not empty.
+func buildWithReport(opt *generator.Option, file string) error {
+	result, err := generator.Build(context.Background(), opt)
+	if file != "" {
+		if reportErr := writeReport(file, result.Report); reportErr != nil {
+			zlog.Warnf("failed to write report: %s, %s", file, reportErr)
+		}
+	}
+	return err
+}
+
+// writeReport writes the report in JSON to file, or stdout if file is "-".
+func writeReport(file string, r *report.Report) error {
+	if file == "-" {
+		return r.WriteJSON(os.Stdout)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := r.WriteJSON(buf); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
 func parseCliOption(c *cli.Context) *generator.Option {
 	configFileItem := loadLocalConfigFile()
 	var option = generator.Option{
 		ConfigFileItem: &configFileItem,
 		EnableWatch:    c.Bool("watch"),
 		EnableDrafts:   c.Bool("drafts"),
+		EnableFuture:   c.Bool("future"),
 		OutputDir:      c.String("output"),
 		BuildArchive:   c.Bool("archive"),
+		DisableCache:   c.Bool("no-cache"),
+		Jobs:           c.Int("jobs"),
+		ValidateFeed:   c.Bool("validate-feed"),
+	}
+	if now := c.String("now"); now != "" {
+		t, err := parseNowTime(now, siteLocation(configFileItem))
+		if err != nil {
+			zlog.Fatalf("invalid --now time: %s, %s", now, err)
+		}
+		option.Clock = func() time.Time { return t }
 	}
 	return &option
 }
+
+// siteLocation returns the timezone of site in config file, UTC if config can not be loaded,
+// the build reports invalid config later.
+func siteLocation(item constants.ConfigFileItem) *time.Location {
+	config, err := configs.LoadFromFile(item)
+	if err != nil {
+		return time.UTC
+	}
+	loc, err := config.Site.Location()
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parseNowTime parses s in layouts of post date, it is in loc if s has no offset, as post dates are.
+func parseNowTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range constants.PostDateLayouts() {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, constants.ErrInvalidPostDate
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"pugo/pkg/core/output"
+
+	"github.com/urfave/cli/v2"
+)
+
+// NewCheck returns a new cli.Command for the check subcommand.
+func NewCheck() *cli.Command {
+	flags := append(globalFlags, pickFlags(genFlags, "drafts")...)
+	flags = append(flags, pickFlags(renderFlags, "future", "now", "jobs", "validate-feed")...)
+	flags = append(flags, pickFlags(buildFlags, "strict", "report", "external")...)
+	cmd := &cli.Command{
+		Name:        "check",
+		Usage:       "check the site",
+		Description: "build the site without writing outputs, report broken links, missing anchors and images",
+		Flags:       flags,
+		Action: func(c *cli.Context) error {
+			initGlobalFlags(c)
+
+			opt := parseCliOption(c)
+			opt.Output = output.Discard
+			opt.Strict = c.Bool("strict")
+			opt.CheckLinks = true
+			opt.CheckExternal = c.Bool("external")
+
+			return buildWithReport(opt, c.String("report"))
+		},
+	}
+	return cmd
+}
+
+// pickFlags returns flags with the names.
+func pickFlags(flags []cli.Flag, names ...string) []cli.Flag {
+	var result []cli.Flag
+	for _, f := range flags {
+		for _, name := range names {
+			if f.Names()[0] == name {
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"os"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/utils"
 	"pugo/pkg/utils/zlog"
@@ -24,6 +25,10 @@ func GetGlobalFlags() []cli.Flag {
 }
 
 func initGlobalFlags(c *cli.Context) {
+	// stdout is used by report
+	if c.String("report") == "-" {
+		zlog.SetOutput(os.Stderr)
+	}
 	zlog.Infof("%s %s", c.App.Name, c.App.Version)
 	// set debug mode
 	if c.Bool("debug") {
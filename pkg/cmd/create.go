@@ -101,7 +101,7 @@ func createSamplePost(filename string, cfg *configs.Config, item constants.Confi
 		Title:        normalizeTitle(slug),
 		Slug:         slug,
 		Descripition: "",
-		DateString:   time.Now().Format("2006-01-02 15:04:05"),
+		DateString:   nowDateString(cfg.Site),
 		Tags:         []string{"post"},
 		Template:     "post.html",
 		AuthorName:   cfg.Author[0].Name,
@@ -128,7 +128,7 @@ func createSamplePage(filename string, cfg *configs.Config, item constants.Confi
 			Title:        normalizeTitle(slug),
 			Slug:         slug,
 			Descripition: "this is an empty page",
-			DateString:   time.Now().Format("2006-01-02 15:04:05"),
+			DateString:   nowDateString(cfg.Site),
 			Template:     "page.html",
 			AuthorName:   cfg.Author[0].Name,
 		},
@@ -144,6 +144,20 @@ func createSamplePage(filename string, cfg *configs.Config, item constants.Confi
 
 var replacer = strings.NewReplacer("-", " ", "_", " ")
 
+// nowDateString returns current time in site timezone as content date.
+// If site is nil, such as no config file yet, it is local time with offset.
+func nowDateString(site *configs.Site) string {
+	if site == nil {
+		return time.Now().Format("2006-01-02 15:04:05 -07:00")
+	}
+	loc, err := site.Location()
+	if err != nil {
+		zlog.Warnf("invalid site timezone: %s, %s", site.Timezone, err)
+		loc = time.Local
+	}
+	return time.Now().In(loc).Format(constants.PostDateLayouts()[0])
+}
+
 func normalizeTitle(name string) string {
 	name = replacer.Replace(name)
 	return strings.Title(strings.ToLower(name))
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"pugo/pkg/core/configs"
+	"pugo/pkg/ext/highlight"
+	"pugo/pkg/utils"
+	"pugo/pkg/utils/zlog"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// NewHighlight returns a new cli.Command for the highlight subcommand.
+func NewHighlight() *cli.Command {
+	flags := append(globalFlags,
+		&cli.StringFlag{
+			Name:  "style",
+			Usage: "style of highlighted code, use build config if empty",
+		},
+		&cli.StringFlag{
+			Name:  "dark-style",
+			Usage: "style in dark mode of theme, use build config if style is not set",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "write css to the file instead of stdout",
+		},
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "list all styles",
+		},
+	)
+	cmd := &cli.Command{
+		Name:        "highlight",
+		Usage:       "generate css of highlighted code",
+		Description: "generate css of the style for code blocks highlighted in building, such as 'pugo highlight --style monokai -o static/css/highlight.css'",
+		Flags:       flags,
+		Action: func(c *cli.Context) error {
+			if c.Bool("list") {
+				fmt.Println(strings.Join(highlight.Styles(), "\n"))
+				return nil
+			}
+
+			// styles in site config are used by default
+			cfg := configs.DefaultBuild().Highlight
+			if item := loadLocalConfigFile(); utils.IsFileExist(item.File) {
+				config, err := configs.LoadFromFile(item)
+				if err != nil {
+					return err
+				}
+				cfg = config.Build.Highlight
+			}
+			style, darkStyle := cfg.Style, cfg.DarkStyle
+			if c.IsSet("style") {
+				style, darkStyle = c.String("style"), ""
+			}
+			if c.IsSet("dark-style") {
+				darkStyle = c.String("dark-style")
+			}
+
+			var buf bytes.Buffer
+			if err := highlight.WriteCSS(&buf, style, darkStyle); err != nil {
+				return err
+			}
+			output := c.String("output")
+			if output == "" {
+				fmt.Print(buf.String())
+				return nil
+			}
+			if err := utils.WriteFile(output, buf.Bytes()); err != nil {
+				return err
+			}
+			zlog.Infof("highlight css generated: %s", output)
+			return nil
+		},
+	}
+	return cmd
+}
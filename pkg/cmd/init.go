@@ -11,7 +11,6 @@ import (
 	"pugo/pkg/utils"
 	"pugo/pkg/utils/zlog"
 	"pugo/themes"
-	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/urfave/cli/v2"
@@ -144,7 +143,7 @@ func initFirstPost() error {
 		Title:        "Hello World",
 		Slug:         "hello-world",
 		Descripition: "this is a demo post",
-		DateString:   time.Now().Format("2006-01-02 15:04:05"),
+		DateString:   nowDateString(nil),
 		Tags:         []string{"hello"},
 		Template:     "post.html",
 		AuthorName:   "admin",
@@ -168,7 +167,7 @@ func initFirstPage() error {
 			Title:        "About",
 			Slug:         "about/",
 			Descripition: "this is a demo page",
-			DateString:   time.Now().Format("2006-01-02 15:04:05"),
+			DateString:   nowDateString(nil),
 			Template:     "page.html",
 			AuthorName:   "admin",
 			Comment:      true,
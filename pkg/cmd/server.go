@@ -14,12 +14,17 @@ var (
 			Value: "18080",
 			Usage: "port to listen",
 		},
+		&cli.BoolFlag{
+			Name:  "memory",
+			Usage: "serve generated files from memory, not write to output directory",
+		},
 	}
 )
 
 // NewServer returns a new cli.Command for the server subcommand.
 func NewServer() *cli.Command {
 	flags := append(globalFlags, genFlags...)
+	flags = append(flags, renderFlags...)
 	flags = append(flags, serverFlags...)
 	cmd := &cli.Command{
 		Name:        "server",
@@ -33,11 +38,22 @@ func NewServer() *cli.Command {
 			opt := parseCliOption(c)
 			opt.IsLocalServer = true
 
+			liveReload := server.NewLiveReload()
+			opt.OnGenerated = liveReload.Notify
+
+			var memory *server.MemoryStore
+			if c.Bool("memory") {
+				memory = server.NewMemoryStore()
+				opt.MemoryOutput = memory.Update
+			}
+
 			generator.Generate(opt)
 
 			s := server.New(server.ServerOption{
-				Port: c.Int("port"),
-				Dir:  opt.OutputDir,
+				Port:       c.Int("port"),
+				Dir:        opt.OutputDir,
+				LiveReload: liveReload,
+				Memory:     memory,
 			})
 			s.Run()
 
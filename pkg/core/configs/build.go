@@ -1,5 +1,13 @@
 package configs
 
+import (
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/assets"
+	"pugo/pkg/ext/highlight"
+	"pugo/pkg/ext/images"
+	"pugo/pkg/ext/toc"
+)
+
 // Build is configuration for building site
 type Build struct {
 	OutputDir       string   `toml:"output_dir"`
@@ -14,7 +22,14 @@ type Build struct {
 
 	ArchivesLink string `toml:"archive_link"`
 
+	Taxonomies []*models.Taxonomy `toml:"taxonomies"`
+
 	EnableMinifyHTML bool `toml:"enable_minify_html"`
+
+	Assets    *assets.Config    `toml:"assets"`
+	Images    *images.Config    `toml:"images"`
+	Highlight *highlight.Config `toml:"highlight"`
+	TOC       *toc.Config       `toml:"toc"`
 }
 
 // DefaultBuild returns a new default build config
@@ -32,6 +47,30 @@ func DefaultBuild() *Build {
 
 		ArchivesLink: "/archives/",
 
+		Taxonomies: []*models.Taxonomy{
+			{
+				Name:           "categories",
+				Title:          "Categories",
+				Hierarchical:   true,
+				LinkFormat:     "/category/{{.Term}}/",
+				PageLinkFormat: "/category/{{.Term}}/{{.Page}}/",
+				IndexLink:      "/categories/",
+			},
+			{
+				Name:           "series",
+				Title:          "Series",
+				Ordered:        true,
+				LinkFormat:     "/series/{{.Term}}/",
+				PageLinkFormat: "/series/{{.Term}}/{{.Page}}/",
+				IndexLink:      "/series/",
+			},
+		},
+
 		EnableMinifyHTML: true,
+
+		Assets:    assets.DefaultConfig(),
+		Images:    images.DefaultConfig(),
+		Highlight: highlight.DefaultConfig(),
+		TOC:       toc.DefaultConfig(),
 	}
 }
@@ -2,10 +2,13 @@ package configs
 
 import (
 	"fmt"
+	"os"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/core/models"
 	"pugo/pkg/core/theme"
-	"pugo/pkg/utils"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
@@ -15,6 +18,7 @@ type Config struct {
 	Build     *Build           `toml:"build"`
 	Author    []*models.Author `toml:"author"`
 	Extension *Extension       `toml:"extension"`
+	Languages []*Language      `toml:"languages"`
 }
 
 // GetAuthor gets the author by the given name
@@ -66,18 +70,27 @@ func DefaultConfig() *Config {
 
 // LoadFromFile loads config file.
 func LoadFromFile(item constants.ConfigFileItem) (*Config, error) {
+	data, err := os.ReadFile(item.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %s", err)
+	}
+	return LoadFromBytes(data, item.Type)
+}
+
+// LoadFromBytes loads config from data in the type of config file.
+func LoadFromBytes(data []byte, typ constants.ConfigType) (*Config, error) {
 	config := DefaultConfig()
-	if item.Type == constants.ConfigTypeTOML {
-		if err := utils.LoadTOMLFile(item.File, config); err != nil {
-			return nil, fmt.Errorf("failed to parse config file: %s", err)
-		}
-		return config, nil
+	var err error
+	switch typ {
+	case constants.ConfigTypeTOML:
+		err = toml.Unmarshal(data, config)
+	case constants.ConfigTypeYAML:
+		err = yaml.Unmarshal(data, config)
+	default:
+		return nil, fmt.Errorf("unsupported config file type: %s", typ)
 	}
-	if item.Type == constants.ConfigTypeYAML {
-		if err := utils.LoadYAMLFile(item.File, config); err != nil {
-			return nil, fmt.Errorf("failed to parse config file: %s", err)
-		}
-		return config, nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %s", err)
 	}
-	return nil, fmt.Errorf("unsupported config file type: %s", item.Type)
+	return config, nil
 }
@@ -4,21 +4,27 @@ import (
 	"pugo/pkg/ext/analytics"
 	"pugo/pkg/ext/comments"
 	"pugo/pkg/ext/feed"
+	"pugo/pkg/ext/redirect"
+	"pugo/pkg/ext/search"
 	"pugo/pkg/ext/sitemap"
 )
 
 type Extension struct {
 	Feed      *feed.Config      `toml:"feed"`
 	Sitemap   *sitemap.Config   `toml:"sitemap"`
+	Search    *search.Config    `toml:"search"`
 	Analytics *analytics.Config `toml:"analytics"`
 	Comments  *comments.Config  `toml:"comments"`
+	Redirect  *redirect.Config  `toml:"redirect"`
 }
 
 func defaultExtension() *Extension {
 	return &Extension{
 		Feed:      feed.DefaultConfig(),
 		Sitemap:   sitemap.DefaultConfig(),
+		Search:    search.DefaultConfig(),
 		Analytics: analytics.DefaultConfig(),
 		Comments:  comments.DefaultConfig(),
+		Redirect:  redirect.DefaultConfig(),
 	}
 }
@@ -0,0 +1,119 @@
+package configs
+
+import (
+	"pugo/pkg/core/models"
+	"strings"
+)
+
+// Language is a language of multilingual site.
+// The first language is the default language, it has no link prefix unless set.
+type Language struct {
+	Code        string            `toml:"code"`
+	Name        string            `toml:"name"`
+	Title       string            `toml:"title"`
+	SubTitle    string            `toml:"sub_title"`
+	Description string            `toml:"description"`
+	Prefix      string            `toml:"prefix"`      // link prefix, it is "/<code>" if empty
+	ContentDir  string            `toml:"content_dir"` // directory with posts and pages in this language, optional
+	Menu        []*models.Menu    `toml:"menu"`
+	Strings     map[string]string `toml:"strings"` // translated site strings for themes
+
+	Link string `toml:"-"` // home link of language
+}
+
+// T returns the translated string of key, or the key itself if not translated.
+func (l *Language) T(key string) string {
+	if l != nil {
+		if s := l.Strings[key]; s != "" {
+			return s
+		}
+	}
+	return key
+}
+
+// GetLanguageCodes returns codes of all languages.
+func (c *Config) GetLanguageCodes() []string {
+	codes := make([]string, 0, len(c.Languages))
+	for _, l := range c.Languages {
+		codes = append(codes, l.Code)
+	}
+	return codes
+}
+
+// LanguageLinkPrefix returns link prefix of language without trailing slash.
+func (c *Config) LanguageLinkPrefix(lang *Language) string {
+	prefix := lang.Prefix
+	if prefix == "" {
+		if len(c.Languages) > 0 && c.Languages[0] == lang {
+			return ""
+		}
+		prefix = lang.Code
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// ForLanguage returns a copy of config for the language,
+// site information and menu are overridden, and all links have language prefix.
+func (c *Config) ForLanguage(lang *Language) *Config {
+	prefix := c.LanguageLinkPrefix(lang)
+	cfg := *c
+
+	site := *c.Site
+	if lang.Title != "" {
+		site.Title = lang.Title
+	}
+	if lang.SubTitle != "" {
+		site.SubTitle = lang.SubTitle
+	}
+	if lang.Description != "" {
+		site.Description = lang.Description
+	}
+	cfg.Site = &site
+	if len(lang.Menu) > 0 {
+		cfg.Menu = lang.Menu
+	}
+
+	build := *c.Build
+	build.PostLinkFormat = prefix + build.PostLinkFormat
+	build.TagLinkFormat = prefix + build.TagLinkFormat
+	build.TagPageLinkFormat = prefix + build.TagPageLinkFormat
+	build.PostPageLinkFormat = prefix + build.PostPageLinkFormat
+	build.ArchivesLink = prefix + build.ArchivesLink
+	build.Taxonomies = make([]*models.Taxonomy, 0, len(c.Build.Taxonomies))
+	for _, tx := range c.Build.Taxonomies {
+		tx2 := *tx
+		tx2.LinkFormat = prefix + tx2.LinkFormat
+		tx2.PageLinkFormat = prefix + tx2.PageLinkFormat
+		if tx2.IndexLink != "" {
+			tx2.IndexLink = prefix + tx2.IndexLink
+		}
+		build.Taxonomies = append(build.Taxonomies, &tx2)
+	}
+	cfg.Build = &build
+
+	ext := *c.Extension
+	if ext.Feed != nil {
+		ext.Feed = ext.Feed.WithLinkPrefix(prefix)
+	}
+	if ext.Search != nil {
+		search := *ext.Search
+		search.Link = prefix + search.Link
+		ext.Search = &search
+	}
+	cfg.Extension = &ext
+
+	cfg.Author = make([]*models.Author, 0, len(c.Author))
+	for _, a := range c.Author {
+		a2 := *a
+		if a2.Slug == "" {
+			a2.Slug = models.AuthorSlug(a2.Name)
+		}
+		a2.Slug = prefix + a2.Slug
+		cfg.Author = append(cfg.Author, &a2)
+	}
+	return &cfg
+}
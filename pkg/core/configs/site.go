@@ -1,6 +1,9 @@
 package configs
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 type Site struct {
 	Title       string   `toml:"title"`
@@ -8,6 +11,15 @@ type Site struct {
 	Base        string   `toml:"base"`
 	Description string   `toml:"description"`
 	Keywords    []string `toml:"keywords"`
+	Timezone    string   `toml:"timezone"`
+}
+
+// Location returns the location of site timezone, it is UTC if timezone is empty.
+func (sc *Site) Location() (*time.Location, error) {
+	if sc.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(sc.Timezone)
 }
 
 // FullURL returns the full url after the base.
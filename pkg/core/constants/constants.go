@@ -31,6 +31,13 @@ const (
 	ContentPagesDir = "content/pages"
 )
 
+const (
+	// BuildCacheFile is the manifest file for incremental building.
+	BuildCacheFile = ".pugo/cache"
+	// ImageCacheDir keeps processed images between builds.
+	ImageCacheDir = ".pugo/images"
+)
+
 var (
 	initDirectories = []string{
 		ContentPostsDir,
@@ -10,4 +10,6 @@ var (
 	ErrInvalidPostStartLine = fmt.Errorf("invalid content start")
 	// ErrInvalidPostDate means the post date is invalid, it must be format as postDefaultDateLayout
 	ErrInvalidPostDate = fmt.Errorf("invalid content date, it must be format as " + strings.Join(postDateLayouts, " or "))
+	// ErrConfigFileNotFound means no config file is in site root.
+	ErrConfigFileNotFound = fmt.Errorf("config file not found")
 )
@@ -1,9 +1,15 @@
 package constants
 
+import "time"
+
 const (
 	PostTemplate     = "post.html"
 	PostListTemplate = "post-list.html"
 	ArchivesTemplate = "archives.html"
+	TaxonomyTemplate = "taxonomy.html"
+
+	// ShortcodeTemplateDir is the dir of shortcode templates in theme, such as "shortcodes/youtube.html".
+	ShortcodeTemplateDir = "shortcodes"
 )
 
 type postMetaSeperator struct {
@@ -27,6 +33,9 @@ var (
 	}
 	postDateLayouts = []string{
 		"2006-01-02 15:04:05",
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -07:00",
 		"2006-01-02 15:04",
 		"2006-01-02",
 	}
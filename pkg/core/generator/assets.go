@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"pugo/pkg/ext/assets"
+	"pugo/pkg/utils"
+	"strings"
+)
+
+// buildAssets processes static files by the asset pipeline if enabled, processed files are set as outputs.
+// It returns the hash of processed assets, or empty if the pipeline is disabled or failed.
+func (ctx *Context) buildAssets(s *SiteData) string {
+	cfg := s.BuildConfig.Assets
+	if cfg == nil || !cfg.Enabled {
+		return ""
+	}
+
+	files, err := ctx.staticFiles()
+	if err != nil {
+		ctx.report.Errorf("", 0, "assets: %s", err)
+		return ""
+	}
+
+	pipelineCfg := *cfg
+	pipelineCfg.Bundles = cfg.Bundles[:len(cfg.Bundles):len(cfg.Bundles)]
+	for i := range s.Render.GetConfig().Bundles {
+		pipelineCfg.Bundles = append(pipelineCfg.Bundles, &s.Render.GetConfig().Bundles[i])
+	}
+	p, err := assets.Build(&pipelineCfg, ctx.source, files)
+	if err != nil {
+		ctx.report.Errorf("", 0, "assets: %s", err)
+		return ""
+	}
+	s.Render.SetAssets(p)
+	ctx.assets = p
+	for _, a := range p.Assets() {
+		ctx.SetOutput(filepath.Join(ctx.outputDir, filepath.FromSlash(a.Link)), a.Link, bytes.NewBuffer(a.Data))
+	}
+	ctx.log.Infof("assets processed: %d files", len(p.Assets()))
+	return p.Hash()
+}
+
+// staticFiles returns paths of files in copy dirs by their links,
+// links are logical names of assets and src of images.
+func (ctx *Context) staticFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range ctx.copingDirs {
+		if !isFSDir(ctx.source, dir.SrcDir) {
+			continue
+		}
+		err := fs.WalkDir(ctx.source, dir.SrcDir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || utils.IsTempFile(file) {
+				return err
+			}
+			relPath := strings.TrimPrefix(file, dir.SrcDir+"/")
+			if dir.SrcDir == "." {
+				relPath = file
+			}
+			files[path.Join("/", filepath.ToSlash(dir.DestDir), relPath)] = file
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", dir.SrcDir, err)
+		}
+	}
+	return files, nil
+}
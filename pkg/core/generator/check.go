@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/linkcheck"
+)
+
+// checkLinks checks links, anchors and assets in rendered html outputs,
+// problems are added to the report of ctx.
+func checkLinks(siteData *SiteData, ctx *Context, opt *Option, outputs []*models.OutputFile) error {
+	sources := make(map[string]string)
+	for _, s := range append([]*SiteData{siteData}, siteData.LanguageSites...) {
+		for _, posts := range [][]*models.Post{s.Posts, s.ExpiredPosts} {
+			for _, p := range posts {
+				sources[p.Link] = p.LocalFile()
+			}
+		}
+		for _, pg := range s.Pages {
+			sources[pg.Link] = pg.LocalFile()
+		}
+	}
+
+	params := &linkcheck.Params{
+		BaseURL:  siteData.SiteConfig.Base,
+		External: opt.CheckExternal,
+		Jobs:     opt.Jobs,
+	}
+	for _, f := range ctx.GetRecordFiles() {
+		if rel, err := filepath.Rel(opt.OutputDir, f.Path); err == nil {
+			params.Files = append(params.Files, filepath.ToSlash(rel))
+		}
+	}
+	for _, o := range outputs {
+		if filepath.Ext(o.Path) != ".html" {
+			continue
+		}
+		rel, err := filepath.Rel(opt.OutputDir, o.Path)
+		if err != nil {
+			continue
+		}
+		params.Pages = append(params.Pages, &linkcheck.Page{
+			Path:   filepath.ToSlash(rel),
+			Link:   o.Link,
+			Source: sources[o.Link],
+			Data:   o.Buf.Bytes(),
+		})
+	}
+
+	problems, err := linkcheck.Check(ctx.buildCtx, params)
+	if err != nil {
+		return err
+	}
+	// problems are reported in output files, lines in source files are unknown
+	outputDir := opt.OutputDir
+	if opt.RootDir != "" {
+		if rel, err := filepath.Rel(opt.RootDir, outputDir); err == nil {
+			outputDir = rel
+		}
+	}
+	for _, p := range problems {
+		file := filepath.ToSlash(filepath.Join(outputDir, p.Page.Path))
+		line := p.Line
+		// lines are in html before minifying
+		if siteData.BuildConfig.EnableMinifyHTML {
+			line = 0
+		}
+		msg := p.Message
+		if p.Page.Source != "" {
+			msg += ", in " + p.Page.Source
+		}
+		if p.Warning {
+			ctx.report.Warnf(file, line, "%s", msg)
+		} else {
+			ctx.report.Errorf(file, line, "%s", msg)
+		}
+	}
+	ctx.log.Infof("check links: %d pages, %d problems", len(params.Pages), len(problems))
+	return nil
+}
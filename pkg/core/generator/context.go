@@ -2,13 +2,28 @@ package generator
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/core/models"
+	"pugo/pkg/core/output"
+	"pugo/pkg/core/report"
+	"pugo/pkg/core/theme"
+	"pugo/pkg/ext/assets"
+	"pugo/pkg/ext/images"
+	"pugo/pkg/ext/markdown"
+	"pugo/pkg/ext/sitemap"
+	"pugo/pkg/ext/toc"
 	"pugo/pkg/utils"
 	"pugo/pkg/utils/zlog"
+	"regexp"
 	"sort"
+	"strconv"
 	"sync"
 
 	"go.uber.org/atomic"
@@ -26,18 +41,120 @@ type Context struct {
 	tagLinkTemplate  *template.Template
 
 	allLinkFiles sync.Map
+	changedFiles sync.Map
+
+	cache     *buildCache
+	converted sync.Map
+	toc       *toc.Config
+
+	// per-build states, shared by language contexts
+	buildCtx  context.Context
+	log       zlog.Logger
+	report    *report.Report
+	source    fs.FS
+	outputDir string
+	writer    output.Writer
+	sitemap   *sitemap.Sitemap
+	minifier  *markdown.Minifier
+	converter markdown.ConvertFunc
+	assets    *assets.Pipeline // nil if asset pipeline is disabled
+	images    *images.Processor
+	imageSrcs map[string]string // links of static files to their paths
+}
+
+type convertedPost struct {
+	once sync.Once
+	err  error
+}
+
+// NewContext creates the context of one build, the build stops when c is canceled.
+func NewContext(c context.Context, s *SiteData, opt *Option) *Context {
+	log := opt.logger()
+	ctx := newContext(s, opt, log)
+	if ctx == nil {
+		return nil
+	}
+	ctx.buildCtx = c
+	ctx.report = s.report
+	if ctx.report == nil {
+		ctx.report = report.New(log)
+	}
+	ctx.source = opt.sourceFS()
+	ctx.outputDir = opt.OutputDir
+	ctx.writer = opt.Output
+	if ctx.writer == nil {
+		ctx.writer = output.Dir(opt.OutputDir)
+	}
+	updateThemeCopyDirs(s.Render, ctx)
+	assetsHash := ctx.buildAssets(s)
+	ctx.setupImages(s, opt)
+	// outputs in memory are always rendered
+	useCache := !opt.DisableCache && opt.MemoryOutput == nil && opt.Output == nil && opt.hasCacheRoot()
+	// links are checked in rendered html, so all pages are rendered
+	useCache = useCache && !opt.CheckLinks
+	ctx.cache = newBuildCache(opt.rootPath(constants.BuildCacheFile), siteGlobalHash(s, opt, assetsHash), useCache, log)
+	if ctx.images != nil {
+		ctx.cache.checkImages(ctx.imageStamp)
+	}
+	ctx.sitemap = sitemap.New(s.Config.Extension.Sitemap, s.SiteConfig.Base)
+	ctx.minifier = markdown.NewMinifier(s.BuildConfig.EnableMinifyHTML)
+	ctx.outputHighlightCSS(s)
+	convertOpts := &markdown.Options{
+		Highlight: s.BuildConfig.Highlight,
+		Shortcode: renderShortcode(s.Render),
+	}
+	if ctx.images != nil {
+		convertOpts.Image = ctx.imageSet(s.BuildConfig.Images)
+	}
+	ctx.converter = markdown.NewConvertFunc(convertOpts)
+	return ctx
+}
+
+// newLanguageContext creates context of site in other language,
+// it shares build cache with parent, outputs should be merged to parent after rendering.
+func (ctx *Context) newLanguageContext(s *SiteData, opt *Option) *Context {
+	langCtx := newContext(s, opt, ctx.log)
+	if langCtx == nil {
+		return nil
+	}
+	langCtx.buildCtx = ctx.buildCtx
+	langCtx.report = ctx.report
+	langCtx.source = ctx.source
+	langCtx.outputDir = ctx.outputDir
+	langCtx.writer = ctx.writer
+	langCtx.cache = ctx.cache
+	langCtx.sitemap = ctx.sitemap
+	langCtx.minifier = ctx.minifier
+	langCtx.converter = ctx.converter
+	langCtx.images = ctx.images
+	langCtx.imageSrcs = ctx.imageSrcs
+	return langCtx
+}
+
+// mergeOutputs moves outputs and link files of language context to ctx.
+func (ctx *Context) mergeOutputs(langCtx *Context) {
+	langCtx.outputs.Range(func(key, value interface{}) bool {
+		ctx.outputs.Store(key, value)
+		return true
+	})
+	langCtx.allLinkFiles.Range(func(key, value interface{}) bool {
+		ctx.recordLinkFile(key.(string), value.(string))
+		return true
+	})
 }
 
-func NewContext(s *SiteData, opt *Option) *Context {
+func newContext(s *SiteData, opt *Option, log zlog.Logger) *Context {
 	ctx := &Context{
+		log:           log,
 		templateData:  map[string]interface{}{},
 		copingDirs:    make([]*models.CopyDir, 0, len(s.BuildConfig.StaticAssetsDir)),
 		outputCounter: atomic.NewInt64(0),
+		toc:           s.BuildConfig.TOC,
 	}
 
 	for _, dir := range s.BuildConfig.StaticAssetsDir {
 		ctx.copingDirs = append(ctx.copingDirs, &models.CopyDir{
-			SrcDir:  dir,
+			SrcDir:  path.Clean(filepath.ToSlash(dir)),
 			DestDir: dir,
 		})
 	}
@@ -50,26 +167,35 @@ func NewContext(s *SiteData, opt *Option) *Context {
 	// build post slug template
 	tpl, err := template.New("post-slug").Parse(s.BuildConfig.PostLinkFormat)
 	if err != nil {
-		zlog.Warn("posts: failed to parse post slug template", "err", err)
+		log.Warnf("posts: failed to parse post slug template: %s", err)
 		return nil
 	}
 	ctx.postSlugTemplate = tpl
-	zlog.Debugf("load post slug template: %s", s.BuildConfig.PostLinkFormat)
+	log.Debugf("load post slug template: %s", s.BuildConfig.PostLinkFormat)
 
 	// build tag link template
 	tpl, err = template.New("tag").Parse(s.BuildConfig.TagLinkFormat)
 	if err != nil {
-		zlog.Warn("posts: failed to parse tag link template", "err", err)
+		log.Warnf("posts: failed to parse tag link template: %s", err)
 		return nil
 	}
 	ctx.tagLinkTemplate = tpl
-	zlog.Debugf("load tag link template: %s", s.BuildConfig.TagLinkFormat)
+	log.Debugf("load tag link template: %s", s.BuildConfig.TagLinkFormat)
 
 	// prepare global template data
 	ctx.templateData["site"] = s.Config.Site
 	ctx.templateData["menu"] = s.Config.Menu
-	// TODO: support authors list
 	ctx.templateData["author"] = s.Config.Author[0]
+	ctx.templateData["lang"] = s.Language
+	ctx.templateData["languages"] = s.Config.Languages
+
+	// update author data, only authors with posts have pages
+	var authorTemplateData []*models.Author
+	for _, authorData := range s.Authors {
+		updateAuthorLink(authorData.Author)
+		authorTemplateData = append(authorTemplateData, authorData.Author)
+	}
+	ctx.templateData["authors"] = authorTemplateData
 
 	// update tag data
 	var tagTemplateData []*models.TagLink
@@ -78,6 +204,21 @@ func NewContext(s *SiteData, opt *Option) *Context {
 		tagTemplateData = append(tagTemplateData, tagData.Tag)
 	}
 	ctx.templateData["tags"] = tagTemplateData
+
+	// update taxonomy term data
+	taxonomyTemplateData := make(map[string]*models.TaxonomyTerms)
+	for _, terms := range s.Taxonomies {
+		tpl, err := template.New("taxonomy-" + terms.Taxonomy.Name).Parse(terms.Taxonomy.LinkFormat)
+		if err != nil {
+			log.Warnf("posts: failed to parse taxonomy link template: %s, %s", terms.Taxonomy.Name, err)
+			return nil
+		}
+		for _, t := range terms.Terms {
+			updateTermLink(tpl, t)
+		}
+		taxonomyTemplateData[terms.Taxonomy.Name] = terms
+	}
+	ctx.templateData["taxonomies"] = taxonomyTemplateData
 	// add pugo data
 	ctx.templateData["pugo"] = map[string]interface{}{
 		"Name":    constants.AppName(),
@@ -88,6 +229,7 @@ func NewContext(s *SiteData, opt *Option) *Context {
 		"Local": opt.IsLocalServer,
 	}
 	ctx.templateData["extension"] = s.Config.Extension
+	ctx.templateData["highlight"] = s.BuildConfig.Highlight
 
 	themeConfig := s.Render.GetConfig()
 	ctx.templateData["theme"] = map[string]interface{}{
@@ -109,6 +251,26 @@ func (ctx *Context) updateTagLink(t *models.TagLink) {
 	t.LocalFile = utils.FormatIndexHTML(buf.String())
 }
 
+func updateAuthorLink(a *models.Author) {
+	// slug is escaped link, local file uses raw name
+	localFile, err := url.PathUnescape(a.Slug)
+	if err != nil {
+		localFile = a.Slug
+	}
+	a.LocalFile = utils.FormatIndexHTML(localFile)
+}
+
+func updateTermLink(tpl *template.Template, t *models.TaxonomyTerm) {
+	data := map[string]interface{}{
+		"Term": t.Name,
+	}
+	var buf bytes.Buffer
+	tpl.Execute(&buf, data)
+
+	t.Link = buf.String()
+	t.LocalFile = utils.FormatIndexHTML(buf.String())
+}
+
 // SetOutput sets the output for the given key.
 func (ctx *Context) SetOutput(path, link string, buf *bytes.Buffer) *Context {
 	ctx.outputs.Store(path, &models.OutputFile{
@@ -176,7 +338,104 @@ func (ctx *Context) appendCopyDir(srcDir, dstDir string) {
 	ctx.copingDirs = append(ctx.copingDirs, &models.CopyDir{SrcDir: srcDir, DestDir: dstDir})
 }
 
+// GetChangedFiles returns files written, copied or removed in this build.
+func (ctx *Context) GetChangedFiles() []string {
+	var files []string
+	ctx.changedFiles.Range(func(key, value interface{}) bool {
+		files = append(files, key.(string))
+		return true
+	})
+	sort.Strings(files)
+	return files
+}
+
+func (ctx *Context) recordChangedFile(file string) {
+	ctx.changedFiles.Store(file, struct{}{})
+}
+
+// convertPost converts post markdown content only once in a build.
+func (ctx *Context) convertPost(p *models.Post) error {
+	v, _ := ctx.converted.LoadOrStore(p, &convertedPost{})
+	c := v.(*convertedPost)
+	c.once.Do(func() {
+		if c.err = p.Convert(ctx.converter); c.err == nil {
+			p.BuildTOC(ctx.toc)
+		}
+	})
+	return c.err
+}
+
+func (ctx *Context) convertPosts(posts []*models.Post) {
+	for _, p := range posts {
+		if err := ctx.convertPost(p); err != nil {
+			ctx.log.Debugf("failed to convert markdown post: %s, %s", p.LocalFile(), err)
+		}
+	}
+}
+
+// addSitemapURLs adds urls to sitemap in order, nil url is skipped.
+func (ctx *Context) addSitemapURLs(urls ...*sitemap.URL) {
+	for _, u := range urls {
+		if u != nil {
+			ctx.sitemap.Add(u)
+		}
+	}
+}
+
+// isOutputFresh returns true if the output is not changed since last build.
+// The fresh output is recorded as generated and need not render again.
+func (ctx *Context) isOutputFresh(dstFile, depsHash string) bool {
+	if !ctx.cache.isOutputFresh(dstFile, depsHash) {
+		return false
+	}
+	ctx.recordLinkFile(dstFile, dstFile)
+	ctx.keepImages(dstFile)
+	return true
+}
+
 func (ctx *Context) recordLinkFile(link, file string) {
 	ctx.allLinkFiles.Store(link, file)
 	ctx.outputCounter.Inc()
 }
+
+// canceled returns the error if the build is canceled.
+func (ctx *Context) canceled() error {
+	return ctx.buildCtx.Err()
+}
+
+var reTemplateLine = regexp.MustCompile(`template: ([^:\s]+):(\d+):`)
+
+// reportRenderError reports the error of rendering what from file, file is empty for generated pages.
+// The error is reported at the line of theme template if it is in err.
+func (ctx *Context) reportRenderError(r *theme.Render, file, what string, err error) {
+	if m := reTemplateLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		if file != "" {
+			what += " " + file
+		}
+		ctx.report.Errorf(path.Join(r.GetDir(), m[1]), line, "failed to render %s: %s", what, err)
+		return
+	}
+	ctx.report.Errorf(file, 0, "failed to render %s: %s", what, err)
+}
+
+// writeFile writes data to file in output directory.
+func (ctx *Context) writeFile(file string, data []byte) error {
+	name, err := filepath.Rel(ctx.outputDir, file)
+	if err != nil {
+		return err
+	}
+	return ctx.writer.WriteFile(filepath.ToSlash(name), data)
+}
+
+// copyFile copies src file in source filesystem to dst file in output directory.
+func (ctx *Context) copyFile(src, dst string) error {
+	if _, ok := ctx.writer.(output.Dir); ok {
+		return utils.CopyFSFile(ctx.source, src, dst)
+	}
+	data, err := fs.ReadFile(ctx.source, src)
+	if err != nil {
+		return err
+	}
+	return ctx.writeFile(dst, data)
+}
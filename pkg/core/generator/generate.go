@@ -1,77 +1,173 @@
 package generator
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
 	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/core/report"
 	"pugo/pkg/core/watcher"
 	"pugo/pkg/ext"
 	"pugo/pkg/utils"
-	"pugo/pkg/utils/zlog"
 	"time"
 
 	"go.uber.org/atomic"
 )
 
+// Result is the result of one build.
+type Result struct {
+	OutputDir    string
+	Outputs      []*models.OutputFile // rendered files, paths are in output directory
+	Files        []string             // all files in output directory, including copied assets
+	ChangedFiles []string             // files written or removed in this build
+	Timings      Timings
+	Report       *report.Report // diagnostics of the build
+}
+
+// Timings is the duration of each stage of a build.
+type Timings struct {
+	Load   time.Duration // load config, theme and contents
+	Render time.Duration
+	Output time.Duration // write outputs and copy assets
+	Total  time.Duration
+}
+
 // Generate generates current site.
 func Generate(opt *Option) error {
+	_, err := Build(context.Background(), opt)
+	return err
+}
+
+// Build builds the site with opt, it stops with the error of c when c is canceled.
+// The result is returned with the report even if the build fails,
+// contents failed to load or render are reported and fail the build after all outputs are written.
+func Build(c context.Context, opt *Option) (*Result, error) {
 	st := time.Now()
+	log := opt.logger()
+	rep := report.New(log)
+	result := &Result{Report: rep}
 
-	siteData, err := CreateSiteData(*opt.ConfigFileItem, &SiteDataParams{
+	item := opt.ConfigFileItem
+	if item == nil {
+		found, err := opt.findConfigFile()
+		if err != nil {
+			rep.Errorf("", 0, "load site data failed: %v", err)
+			return result, err
+		}
+		item = found
+	}
+	siteData, err := CreateSiteData(*item, &SiteDataParams{
 		WithDrafts: opt.EnableDrafts,
+		WithFuture: opt.EnableFuture,
+		Now:        opt.now(),
+		FS:         opt.sourceFS(),
+		Logger:     log,
+		Report:     rep,
 	})
 	if err != nil {
-		zlog.Warnf("load site data failed: %v", err)
-		return err
+		return result, err
 	}
 	if opt.OutputDir == "" {
 		opt.OutputDir = siteData.BuildConfig.OutputDir
 	}
-	zlog.Infof("output dir: %s", opt.OutputDir)
+	outputDir := opt.OutputDir
+	opt.OutputDir = opt.rootPath(opt.OutputDir)
+	// restore it for next build in watching
+	defer func() { opt.OutputDir = outputDir }()
+	log.Infof("output dir: %s", opt.OutputDir)
+	result.OutputDir = opt.OutputDir
+	result.Timings.Load = time.Since(st)
 
 	// TODO: use a method to contains all extensions initialization
-	ext.Reload(siteData.Config)
+	ext.Reload(siteData.Config, log)
 
-	context := NewContext(siteData, opt)
+	context := NewContext(c, siteData, opt)
+	if context == nil {
+		return result, fmt.Errorf("failed to create build context")
+	}
 
+	if err = context.canceled(); err != nil {
+		return result, err
+	}
+	renderStart := time.Now()
 	if err = Render(siteData, context, opt); err != nil {
-		zlog.Warnf("render failed: %v", err)
-		return err
+		rep.Errorf("", 0, "render failed: %v", err)
+		return result, err
 	}
+	result.Timings.Render = time.Since(renderStart)
 
+	outputStart := time.Now()
+	result.Outputs = context.GetOutputs()
 	if err = Output(siteData, context, opt); err != nil {
-		zlog.Warnf("output failed: %v", err)
-		return err
+		rep.Errorf("", 0, "output failed: %v", err)
+		return result, err
+	}
+	result.Timings.Output = time.Since(outputStart)
+	if opt.CheckLinks {
+		if err = checkLinks(siteData, context, opt, result.Outputs); err != nil {
+			rep.Errorf("", 0, "check links failed: %v", err)
+			return result, err
+		}
+	}
+	result.Timings.Total = time.Since(st)
+	for _, f := range context.GetRecordFiles() {
+		result.Files = append(result.Files, f.Path)
 	}
-	zlog.Infof("generate %d files finished in %dms", context.getOutputCounter(), time.Since(st).Milliseconds())
+	result.ChangedFiles = context.GetChangedFiles()
+	log.Infof("generate %d files finished in %dms", context.getOutputCounter(), result.Timings.Total.Milliseconds())
 
-	if opt.EnableWatch && !watchFlag.Load() {
-		go Watch(opt)
+	err = rep.Err(opt.Strict)
+	if err == nil && opt.OnGenerated != nil {
+		opt.OnGenerated(result.ChangedFiles)
 	}
-	return nil
+
+	if opt.EnableWatch && opt.watcher == nil {
+		opt.watcher = newSiteWatcher(opt)
+		go opt.watcher.watch()
+	}
+	return result, err
 }
 
-var (
-	nextGenerateTime = time.Now().Add(time.Second * -1)
-	watchFlag        = atomic.NewBool(false)
-)
+// siteWatcher rebuilds a site when its source files are changed,
+// each site has its own watcher, so sites watched in one process do not interfere.
+type siteWatcher struct {
+	opt     *Option
+	changed *atomic.Bool // set by events, the site is rebuilt in next tick
+}
+
+func newSiteWatcher(opt *Option) *siteWatcher {
+	return &siteWatcher{
+		opt:     opt,
+		changed: atomic.NewBool(false),
+	}
+}
 
+// Watch watches source files of site and rebuilds it when changed.
 func Watch(opt *Option) {
+	if opt.watcher == nil {
+		opt.watcher = newSiteWatcher(opt)
+	}
+	opt.watcher.watch()
+}
+
+func (sw *siteWatcher) watch() {
+	opt := sw.opt
+	log := opt.logger()
+	if opt.SourceFS != nil {
+		log.Warnf("watch failed: sources are not in local directory")
+		return
+	}
 
 	w, err := watcher.New(constants.WatchPollingDuration)
 	if err != nil {
-		zlog.Warnf("watch failed: %v", err)
+		log.Warnf("watch failed: %v", err)
 		return
 	}
 
-	watchFlag.Store(true)
-
 	// use time loop to handle several events at once
 	utils.Ticker(constants.WatchTickerDuaration, func() {
-		delta := nextGenerateTime.Sub(time.Now())
-		// zlog.Debugf("next generate time: %v", delta)
-		if delta > 0 {
-			// if nextGenerateTime is in the future, handle it
-			nextGenerateTime = time.Now().Add(time.Second * -1)
+		if sw.changed.CAS(true, false) {
 			Generate(opt)
 		}
 	})
@@ -82,9 +178,10 @@ func Watch(opt *Option) {
 		if dir == baseDir {
 			continue
 		}
+		dir = opt.rootPath(dir)
 		subDirs, err := utils.GetSubDirectories(dir)
 		if err != nil {
-			zlog.Warnf("get sub directories failed: %v", err)
+			log.Warnf("get sub directories failed: %v", err)
 			allDirs = append(allDirs, dir)
 		} else {
 			allDirs = append(allDirs, subDirs...)
@@ -93,15 +190,15 @@ func Watch(opt *Option) {
 	allDirs = utils.UniqueStringsSlice(allDirs)
 	for _, dir := range allDirs {
 		w.Add(dir)
-		zlog.Debugf("watching dir: %s", dir)
+		log.Debugf("watching dir: %s", dir)
 	}
 	go func() {
 		for {
 			event := <-w.Events()
-			zlog.Infof("wathcing event: %s, %v", event.Name, event.Op)
-			// set a future time to avoid generating too frequently
-			nextGenerateTime = time.Now().Add(time.Hour)
+			log.Infof("wathcing event: %s, %v", event.Name, event.Op)
+			// rebuild in next tick to avoid generating too frequently
+			sw.changed.Store(true)
 		}
 	}()
-	zlog.Infof("watching...")
+	log.Infof("watching...")
 }
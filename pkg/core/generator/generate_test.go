@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/core/output"
+	"pugo/pkg/core/report"
+	"pugo/pkg/utils"
+	"pugo/pkg/utils/zlog"
+	"pugo/themes"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testSite(t *testing.T) fstest.MapFS {
+	files := fstest.MapFS{
+		"config.toml":            {Data: []byte("[site]\n  title = \"Test\"\n  base = \"https://example.com\"\n")},
+		"content/posts/hello.md": {Data: []byte("---\ntitle: Hello\nslug: hello\ndate: 2022-02-01 10:00:00\n---\nhello\n")},
+		"content/pages/about.md": {Data: []byte("---\ntitle: About\nslug: about\n---\nabout\n")},
+	}
+	err := fs.WalkDir(themes.DefaultAssets, "default", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := themes.DefaultAssets.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[path.Join("themes", file)] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestBuildNotifyOnlySuccess(t *testing.T) {
+	var notified int
+	build := func(files fstest.MapFS) error {
+		_, err := Build(context.Background(), &Option{
+			SourceFS:    files,
+			Output:      output.NewMemory(),
+			Logger:      zlog.Nop(),
+			OnGenerated: func([]string) { notified++ },
+		})
+		return err
+	}
+
+	files := testSite(t)
+	if err := build(files); err != nil {
+		t.Fatal(err)
+	}
+	if notified != 1 {
+		t.Fatalf("successful build should notify once, got: %d", notified)
+	}
+
+	files["content/posts/broken.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Broken\ndate: 2022-13-45\n---\nbroken\n")}
+	if err := build(files); !errors.Is(err, report.ErrFailed) {
+		t.Fatalf("build should fail with content errors, got: %v", err)
+	}
+	if notified != 1 {
+		t.Fatalf("failed build should not notify, got: %d", notified)
+	}
+}
+
+func TestBuildUnknownAuthorEmail(t *testing.T) {
+	files := testSite(t)
+	files["config.toml"].Data = append(files["config.toml"].Data, []byte("[extension.feed.rss]\n  enabled = true\n")...)
+	files["content/posts/guest.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Guest\nslug: guest\nauthor: guest\ndate: 2022-02-02 10:00:00\n---\nguest\n")}
+
+	out := output.NewMemory()
+	if _, err := Build(context.Background(), &Option{SourceFS: files, Output: out, Logger: zlog.Nop()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"atom.xml", "rss.xml"} {
+		data, err := out.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(data, []byte("guest")) || bytes.Contains(data, []byte("guest@example.com")) {
+			t.Fatalf("%s should have unknown author without demo email: %s", file, data)
+		}
+	}
+}
+
+func TestBuildRedirects(t *testing.T) {
+	files := testSite(t)
+	files["config.toml"].Data = append(files["config.toml"].Data, []byte("[extension.redirect]\n  enabled = true\n  netlify_file = \"/_redirects\"\n")...)
+	files["content/posts/hello.md"].Data = []byte("---\ntitle: Hello\nslug: hello\naliases: [/old/hello/]\ndate: 2022-02-01 10:00:00\n---\nhello\n")
+
+	out := output.NewMemory()
+	if _, err := Build(context.Background(), &Option{SourceFS: files, Output: out, Logger: zlog.Nop()}); err != nil {
+		t.Fatal(err)
+	}
+	stub, err := out.ReadFile("old/hello/index.html")
+	if err != nil || !bytes.Contains(stub, []byte("https://example.com/2022/02/hello/")) {
+		t.Fatalf("alias should redirect to post: %s, %v", stub, err)
+	}
+	if data, _ := out.ReadFile("_redirects"); string(data) != "/old/hello/ /2022/02/hello/ 301\n" {
+		t.Fatalf("unexpected redirects file: %q", data)
+	}
+
+	// alias of existing page fails the build
+	files["content/pages/about.md"].Data = []byte("---\ntitle: About\nslug: about\naliases: [/2022/02/hello/]\n---\nabout\n")
+	if _, err := Build(context.Background(), &Option{SourceFS: files, Output: output.NewMemory(), Logger: zlog.Nop()}); !errors.Is(err, report.ErrFailed) {
+		t.Fatalf("alias of existing page should fail the build, got: %v", err)
+	}
+}
+
+func TestBuildMemoryChangedFiles(t *testing.T) {
+	files := testSite(t)
+	var changed []string
+	opt := &Option{
+		SourceFS:     files,
+		Logger:       zlog.Nop(),
+		MemoryOutput: func([]*models.OutputFile, []*models.CopyDir) {},
+		OnGenerated:  func(files []string) { changed = files },
+	}
+	build := func() []string {
+		changed = nil
+		if _, err := Build(context.Background(), opt); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(changed))
+		for i, file := range changed {
+			names[i] = filepath.ToSlash(strings.TrimPrefix(file, "build"+string(filepath.Separator)))
+		}
+		return names
+	}
+
+	if names := build(); len(names) == 0 {
+		t.Fatal("first build should change all files")
+	}
+	if names := build(); len(names) != 0 {
+		t.Fatalf("unchanged site should change nothing: %v", names)
+	}
+
+	style := files["themes/default/static/css/style.css"]
+	files["themes/default/static/css/style.css"] = &fstest.MapFile{Data: append(style.Data, "\nbody{}\n"...)}
+	if names := build(); len(names) != 1 || names[0] != "static/css/style.css" {
+		t.Fatalf("only edited css should change: %v", names)
+	}
+
+	files["content/posts/hello.md"].Data = append(files["content/posts/hello.md"].Data, "more\n"...)
+	if names := build(); !utils.Contains(names, "2022/02/hello/index.html") || utils.Contains(names, "about/index.html") {
+		t.Fatalf("only outputs of edited post should change: %v", names)
+	}
+}
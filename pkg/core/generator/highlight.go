@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"bytes"
+	"path/filepath"
+	"pugo/pkg/ext/highlight"
+)
+
+// outputHighlightCSS generates css of highlighted code if enabled,
+// a static file at the same link is used instead, such as one generated by "pugo highlight".
+func (ctx *Context) outputHighlightCSS(s *SiteData) {
+	cfg := s.BuildConfig.Highlight
+	if cfg == nil || !cfg.Enabled || cfg.CSSFile == "" {
+		return
+	}
+	files, err := ctx.staticFiles()
+	if err != nil {
+		ctx.report.Errorf("", 0, "highlight: %s", err)
+		return
+	}
+	if _, ok := files[cfg.CSSFile]; ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := highlight.WriteCSS(&buf, cfg.Style, cfg.DarkStyle); err != nil {
+		ctx.report.Errorf("", 0, "highlight: %s", err)
+		return
+	}
+	ctx.SetOutput(filepath.Join(ctx.outputDir, filepath.FromSlash(cfg.CSSFile)), cfg.CSSFile, &buf)
+}
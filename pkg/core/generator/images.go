@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/images"
+	"pugo/pkg/ext/markdown"
+	"pugo/pkg/ext/sitemap"
+)
+
+// setupImages creates the image processor if enabled.
+func (ctx *Context) setupImages(s *SiteData, opt *Option) {
+	cfg := s.BuildConfig.Images
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	files, err := ctx.staticFiles()
+	if err != nil {
+		ctx.report.Errorf("", 0, "images: %s", err)
+		return
+	}
+
+	// processed images are not cached when outputs are not in output directory or site has no root
+	cacheDir := opt.rootPath(constants.ImageCacheDir)
+	if opt.DisableCache || opt.Output != nil || !opt.hasCacheRoot() {
+		cacheDir = ""
+	}
+	ctx.images = images.NewProcessor(cfg, ctx.source, files, cacheDir)
+	ctx.imageSrcs = files
+}
+
+// imageStamp returns the size and modified time of image link, empty if it is not found.
+// Pages show sizes of images, so changed images rebuild all.
+func (ctx *Context) imageStamp(link string) string {
+	src, ok := ctx.imageSrcs[link]
+	if !ok {
+		return ""
+	}
+	info, err := fs.Stat(ctx.source, src)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())
+}
+
+// recordImages records processed images in content of post as images of the output.
+func (ctx *Context) recordImages(dstFile string, p *models.Post) {
+	if ctx.images == nil {
+		return
+	}
+	var links []string
+	for _, img := range sitemap.ImagesFromHTML(p.Content()) {
+		if ctx.images.Processed(img.Loc) {
+			links = append(links, img.Loc)
+		}
+	}
+	ctx.cache.setOutputImages(dstFile, links)
+}
+
+// keepImages processes images of fresh output, so their variants are still in outputs.
+// Processed images are loaded from cache.
+func (ctx *Context) keepImages(dstFile string) {
+	if ctx.images == nil {
+		return
+	}
+	for _, link := range ctx.cache.outputImages(dstFile) {
+		_, _ = ctx.images.Get(link)
+	}
+}
+
+// imageSet returns the responsive image set of src in markdown, nil if src is not processed.
+// Errors are reported once in outputImages.
+func (ctx *Context) imageSet(cfg *images.Config) markdown.ImageFunc {
+	return func(src string) *markdown.ImageSet {
+		img, err := ctx.images.Get(src)
+		if err != nil || img == nil {
+			return nil
+		}
+		return &markdown.ImageSet{
+			Width:      img.Width,
+			Height:     img.Height,
+			Srcset:     img.Srcset(false),
+			WebPSrcset: img.Srcset(true),
+			Sizes:      cfg.Sizes,
+			Lazy:       cfg.Lazy,
+		}
+	}
+}
+
+// outputImages sets variants of images referenced in contents as outputs,
+// it should run after all contents are converted.
+func (ctx *Context) outputImages() {
+	if ctx.images == nil {
+		return
+	}
+	for _, err := range ctx.images.Errors() {
+		ctx.report.Warnf("", 0, "images: %s", err)
+	}
+	var count int
+	for _, img := range ctx.images.Images() {
+		for _, v := range img.Variants {
+			ctx.SetOutput(filepath.Join(ctx.outputDir, filepath.FromSlash(v.Link)), v.Link, bytes.NewBuffer(v.Data))
+			count++
+		}
+	}
+	stamps := make(map[string]string)
+	for _, img := range ctx.images.Images() {
+		stamps[img.Link] = ctx.imageStamp(img.Link)
+	}
+	ctx.cache.setImages(stamps)
+	if err := ctx.images.Prune(); err != nil {
+		ctx.log.Warnf("images: failed to prune cache: %s", err)
+	}
+	ctx.log.Infof("images processed: %d images, %d files", len(ctx.images.Images()), count)
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// jobsNumber returns the number of workers to run render jobs.
+func jobsNumber(jobs int) int {
+	if jobs <= 0 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// runJobs calls fn for each index in [0, n) with at most jobs workers.
+// It waits for all calls and returns the error of the lowest index,
+// so the result is the same as calling fn one by one.
+// Indexes not started yet are skipped with the error of c if c is canceled.
+func runJobs(c context.Context, jobs, n int, fn func(i int) error) error {
+	jobs = jobsNumber(jobs)
+	if jobs > n {
+		jobs = n
+	}
+	errs := make([]error, n)
+	if jobs <= 1 {
+		for i := 0; i < n; i++ {
+			if err := c.Err(); err != nil {
+				return err
+			}
+			if errs[i] = fn(i); errs[i] != nil {
+				return errs[i]
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if errs[i] = c.Err(); errs[i] == nil {
+					errs[i] = fn(i)
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"pugo/pkg/core/output"
+	"pugo/pkg/utils/zlog"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuildJobsSameOutputs(t *testing.T) {
+	files := testSite(t)
+	for i := 1; i <= 20; i++ {
+		data := fmt.Sprintf("---\ntitle: Post %d\nslug: post-%d\ntags: [tag%d, common]\ndate: 2022-03-%02d 10:00:00\n---\npost %d\n<!--more-->\nmore\n", i, i, i%3, i, i)
+		files[fmt.Sprintf("content/posts/post-%d.md", i)] = &fstest.MapFile{Data: []byte(data)}
+	}
+	build := func(jobs int) *output.Memory {
+		out := output.NewMemory()
+		_, err := Build(context.Background(), &Option{SourceFS: files, Output: out, Logger: zlog.Nop(), Jobs: jobs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	serial, parallel := build(1), build(8)
+	names := serial.Files()
+	if fmt.Sprint(names) != fmt.Sprint(parallel.Files()) {
+		t.Fatalf("builds should have same files:\n%v\n%v", names, parallel.Files())
+	}
+	for _, name := range names {
+		a, _ := serial.ReadFile(name)
+		b, _ := parallel.ReadFile(name)
+		if !bytes.Equal(a, b) {
+			t.Fatalf("%s differs between 1 and 8 jobs", name)
+		}
+	}
+}
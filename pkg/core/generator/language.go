@@ -0,0 +1,233 @@
+package generator
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"pugo/pkg/core/configs"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/sitemap"
+	"pugo/pkg/utils"
+	"strings"
+	"time"
+)
+
+// loadContents loads posts and pages of all languages.
+// Contents in root content directories are in the default language,
+// unless the file has a language suffix like "post.zh.md" or "lang" in front-matter.
+// Contents in language content directory are in that language.
+func loadContents(cfg *configs.Config, params *SiteDataParams, loc *time.Location) ([]*models.Post, []*models.Page, error) {
+	codes := cfg.GetLanguageCodes()
+	defaultLang := ""
+	if len(codes) > 0 {
+		defaultLang = codes[0]
+	}
+
+	loadParams := &models.LoadParams{
+		FS:         params.FS,
+		WithDrafts: params.WithDrafts,
+		Location:   loc,
+		Logger:     params.Logger,
+		Report:     params.Report,
+	}
+	posts, err := models.LoadPosts(constants.ContentPostsDir, loadParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range posts {
+		setContentLanguage(p, constants.ContentPostsDir, "", defaultLang, codes)
+	}
+	pages, err := models.LoadPages(constants.ContentPagesDir, loadParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pg := range pages {
+		setPageLanguage(pg, constants.ContentPagesDir, "", defaultLang, codes)
+	}
+
+	for _, lang := range cfg.Languages {
+		if lang.ContentDir == "" {
+			continue
+		}
+		postsDir := path.Join(filepath.ToSlash(lang.ContentDir), "posts")
+		if isFSDir(params.FS, postsDir) {
+			langPosts, err := models.LoadPosts(postsDir, loadParams)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, p := range langPosts {
+				setContentLanguage(p, postsDir, lang.Code, defaultLang, codes)
+			}
+			posts = append(posts, langPosts...)
+		}
+		pagesDir := path.Join(filepath.ToSlash(lang.ContentDir), "pages")
+		if isFSDir(params.FS, pagesDir) {
+			langPages, err := models.LoadPages(pagesDir, loadParams)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, pg := range langPages {
+				setPageLanguage(pg, pagesDir, lang.Code, defaultLang, codes)
+			}
+			pages = append(pages, langPages...)
+		}
+	}
+	models.SortPosts(posts)
+	return posts, pages, nil
+}
+
+// isFSDir returns true if dir is a directory in fsys.
+func isFSDir(fsys fs.FS, dir string) bool {
+	info, err := fs.Stat(fsys, dir)
+	return err == nil && info.IsDir()
+}
+
+// setContentLanguage sets language and translation key of content,
+// the key is the relative path without language suffix.
+func setContentLanguage(p *models.Post, dir, dirLang, defaultLang string, codes []string) {
+	key, _ := filepath.Rel(dir, p.LocalFile())
+	key = filepath.ToSlash(key)
+	lang := dirLang
+	ext := filepath.Ext(key)
+	if suffix := filepath.Ext(strings.TrimSuffix(key, ext)); suffix != "" && utils.Contains(codes, suffix[1:]) {
+		key = strings.TrimSuffix(key, suffix+ext) + ext
+		if lang == "" {
+			lang = suffix[1:]
+		}
+	}
+	if p.Lang == "" {
+		p.Lang = lang
+	}
+	if p.Lang == "" {
+		p.Lang = defaultLang
+	}
+	if p.TranslationKey == "" {
+		p.TranslationKey = key
+	}
+}
+
+func setPageLanguage(pg *models.Page, dir, dirLang, defaultLang string, codes []string) {
+	rel, _ := filepath.Rel(dir, pg.LocalFile())
+	setContentLanguage(&pg.Post, dir, dirLang, defaultLang, codes)
+	// slug from file path has no language suffix
+	if pg.Slug == rel {
+		pg.Slug = filepath.FromSlash(pg.TranslationKey)
+	}
+}
+
+// createLanguageSites creates site data of each language,
+// the first one is the default language, others are in its LanguageSites.
+func createLanguageSites(base *SiteData, posts []*models.Post, pages []*models.Page, params *SiteDataParams) *SiteData {
+	cfg := base.Config
+	var (
+		sites    []*SiteData
+		contents []*models.Post
+	)
+	for _, lang := range cfg.Languages {
+		prefix := cfg.LanguageLinkPrefix(lang)
+		lang.Link = prefix + "/"
+
+		s := NewSiteData()
+		s.ConfigType = base.ConfigType
+		s.configData = base.configData
+		s.Config = cfg.ForLanguage(lang)
+		s.BuildConfig = s.Config.Build
+		s.SiteConfig = s.Config.Site
+		s.Render = base.Render
+		s.Language = lang
+		s.LinkPrefix = prefix
+
+		var langPosts []*models.Post
+		for _, p := range posts {
+			if p.Lang == lang.Code {
+				langPosts = append(langPosts, p)
+			}
+		}
+		s.Posts, s.ExpiredPosts = models.FilterScheduledPosts(langPosts, params.Now, params.WithFuture, params.Logger)
+		for _, pg := range pages {
+			if pg.Lang != lang.Code {
+				continue
+			}
+			if prefix != "" {
+				pg.Slug = prefix + "/" + strings.TrimPrefix(filepath.ToSlash(pg.Slug), "/")
+			}
+			s.Pages = append(s.Pages, pg)
+			contents = append(contents, &pg.Post)
+		}
+		contents = append(contents, s.Posts...)
+		contents = append(contents, s.ExpiredPosts...)
+
+		s.fullfill(params.Logger)
+		params.Logger.Infof("load language ok: %s, posts: %d, pages: %d", lang.Code, len(s.Posts), len(s.Pages))
+		sites = append(sites, s)
+	}
+
+	// contents in unknown languages are not rendered
+	for _, p := range posts {
+		if !utils.Contains(cfg.GetLanguageCodes(), p.Lang) {
+			params.Report.Warnf(p.LocalFile(), 0, "skip post in unknown language: %s", p.Lang)
+		}
+	}
+	for _, pg := range pages {
+		if !utils.Contains(cfg.GetLanguageCodes(), pg.Lang) {
+			params.Report.Warnf(pg.LocalFile(), 0, "skip page in unknown language: %s", pg.Lang)
+		}
+	}
+
+	linkTranslations(contents)
+
+	sites[0].LanguageSites = sites[1:]
+	return sites[0]
+}
+
+// linkTranslations links contents with the same translation key in different languages.
+func linkTranslations(contents []*models.Post) {
+	groups := make(map[string][]*models.Post)
+	var keys []string
+	for _, p := range contents {
+		if _, ok := groups[p.TranslationKey]; !ok {
+			keys = append(keys, p.TranslationKey)
+		}
+		groups[p.TranslationKey] = append(groups[p.TranslationKey], p)
+	}
+	for _, key := range keys {
+		group := groups[key]
+		for _, p := range group {
+			for _, t := range group {
+				if t != p && t.Lang != p.Lang {
+					p.Translations = append(p.Translations, t)
+				}
+			}
+		}
+	}
+}
+
+// buildContentLinks builds links of posts and pages before rendering,
+// translations in other language sites need the links.
+func buildContentLinks(s *SiteData, ctx *Context) {
+	for _, posts := range [][]*models.Post{s.Posts, s.ExpiredPosts} {
+		for _, p := range posts {
+			if link, _, err := ctx.createPostLink(p); err == nil {
+				p.Link = link
+			}
+		}
+	}
+	for _, pg := range s.Pages {
+		pg.Link = "/" + strings.TrimPrefix(filepath.ToSlash(pg.Slug), "/")
+	}
+}
+
+// translationAlternates returns sitemap alternate links of content and its translations.
+func translationAlternates(p *models.Post) []*sitemap.AlternateLink {
+	if len(p.Translations) == 0 {
+		return nil
+	}
+	links := []*sitemap.AlternateLink{sitemap.NewAlternateLink(p.Lang, p.Link)}
+	for _, t := range p.Translations {
+		if t.Link != "" {
+			links = append(links, sitemap.NewAlternateLink(t.Lang, t.Link))
+		}
+	}
+	return links
+}
@@ -0,0 +1,297 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"pugo/pkg/utils/zlog"
+	"strconv"
+	"sync"
+)
+
+// manifest records the inputs and outputs of a build.
+// It is saved to constants.BuildCacheFile and used by next build to skip unchanged outputs.
+type manifest struct {
+	Version    string                     `json:"version"`
+	GlobalHash string                     `json:"global_hash"`
+	Outputs    map[string]*manifestOutput `json:"outputs"`
+	Assets     map[string]string          `json:"assets"`
+	Images     map[string]string          `json:"images"` // stamps of images processed in contents
+}
+
+// manifestOutput is the record of one output file.
+// DepsHash is the hash of sources and templates the output depends on,
+// Hash is the hash of the written file content.
+type manifestOutput struct {
+	DepsHash string   `json:"deps_hash,omitempty"`
+	Hash     string   `json:"hash,omitempty"`
+	Images   []string `json:"images,omitempty"` // links of processed images in the output
+}
+
+func newManifest(globalHash string) *manifest {
+	return &manifest{
+		Version:    constants.AppVersion(),
+		GlobalHash: globalHash,
+		Outputs:    make(map[string]*manifestOutput),
+		Assets:     make(map[string]string),
+		Images:     make(map[string]string),
+	}
+}
+
+func loadManifest(file string) (*manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest("")
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *manifest) save(file string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(file, data)
+}
+
+// buildCache compares current build with the manifest of last build.
+type buildCache struct {
+	enabled    bool
+	rebuildAll bool
+	file       string
+	last       *manifest
+	current    *manifest
+	pending    map[string]string
+	images     map[string][]string
+	lock       sync.Mutex
+	log        zlog.Logger
+}
+
+func newBuildCache(file, globalHash string, enabled bool, log zlog.Logger) *buildCache {
+	c := &buildCache{
+		log:     log,
+		enabled: enabled,
+		file:    file,
+		current: newManifest(globalHash),
+		pending: make(map[string]string),
+		images:  make(map[string][]string),
+	}
+	if !enabled {
+		return c
+	}
+	last, err := loadManifest(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.log.Warnf("cache: failed to load manifest: %s, %s", file, err)
+		}
+		return c
+	}
+	if last.Version != c.current.Version || last.GlobalHash != globalHash {
+		c.log.Infof("cache: site config or version changed, rebuild all")
+		c.rebuildAll = true
+	}
+	c.last = last
+	return c
+}
+
+// isOutputFresh returns true if the output file is the same as last build.
+// The dependencies hash is kept until the output is written.
+func (c *buildCache) isOutputFresh(path, depsHash string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pending[path] = depsHash
+	if c.last != nil && !c.rebuildAll && depsHash != "" {
+		lastOutput := c.last.Outputs[path]
+		if lastOutput != nil && lastOutput.DepsHash == depsHash && utils.IsFileExist(path) {
+			c.current.Outputs[path] = lastOutput
+			return true
+		}
+	}
+	return false
+}
+
+// isOutputWritten records content hash of the output,
+// and returns true if the same content is already written to the file.
+func (c *buildCache) isOutputWritten(path string, data []byte) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	o := &manifestOutput{
+		DepsHash: c.pending[path],
+		Hash:     utils.MD5Bytes(data),
+		Images:   c.images[path],
+	}
+	c.current.Outputs[path] = o
+	if c.last == nil {
+		return false
+	}
+	lastOutput := c.last.Outputs[path]
+	return lastOutput != nil && lastOutput.Hash == o.Hash && utils.IsFileExist(path)
+}
+
+// checkImages rebuilds all outputs if any image processed in last build is changed,
+// stamp returns the current stamp of image link.
+func (c *buildCache) checkImages(stamp func(link string) string) {
+	if c.last == nil || c.rebuildAll {
+		return
+	}
+	for link, s := range c.last.Images {
+		if stamp(link) != s {
+			c.log.Infof("cache: image changed, rebuild all: %s", link)
+			c.rebuildAll = true
+			return
+		}
+	}
+}
+
+// setImages records stamps of images processed in current build.
+func (c *buildCache) setImages(stamps map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.current.Images = stamps
+}
+
+// setOutputImages records links of processed images in the output to be written.
+func (c *buildCache) setOutputImages(path string, links []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.images[path] = links
+}
+
+// outputImages returns links of processed images in the fresh output.
+func (c *buildCache) outputImages(path string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if o := c.current.Outputs[path]; o != nil {
+		return o.Images
+	}
+	return nil
+}
+
+// isAssetCopied returns true if the asset file is not changed since last build.
+func (c *buildCache) isAssetCopied(src os.FileInfo, dst string) bool {
+	stamp := fmt.Sprintf("%d-%d", src.Size(), src.ModTime().UnixNano())
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.current.Assets[dst] = stamp
+	if c.last == nil {
+		return false
+	}
+	return c.last.Assets[dst] == stamp && utils.IsFileExist(dst)
+}
+
+// removeStaleOutputs removes files generated or copied by last build but not by current build.
+// It returns the removed files.
+func (c *buildCache) removeStaleOutputs() []string {
+	if c.last == nil {
+		return nil
+	}
+	stale := make(map[string]bool)
+	for path := range c.last.Outputs {
+		stale[path] = true
+	}
+	for path := range c.last.Assets {
+		stale[path] = true
+	}
+	var removed []string
+	for path := range stale {
+		if _, ok := c.current.Outputs[path]; ok {
+			continue
+		}
+		if _, ok := c.current.Assets[path]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			c.log.Warnf("cache: failed to remove stale output: %s, %s", path, err)
+			continue
+		}
+		removed = append(removed, path)
+		c.log.Infof("stale output removed: %s", path)
+	}
+	return removed
+}
+
+func (c *buildCache) save() error {
+	if !c.enabled {
+		return nil
+	}
+	return c.current.save(c.file)
+}
+
+// outputDepsHash returns the hash of template and posts that an output depends on.
+func outputDepsHash(tplHash string, posts []*models.Post, extra ...string) string {
+	if tplHash == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString(tplHash)
+	for _, p := range posts {
+		buf.WriteString(p.SourceHash())
+		buf.WriteString(p.Link)
+	}
+	for _, e := range extra {
+		buf.WriteString(e)
+	}
+	return utils.MD5Bytes(buf.Bytes())
+}
+
+// postDepsHash returns the hash of template and posts that a post page depends on,
+// including neighbor posts in ordered taxonomy terms.
+func postDepsHash(tplHash string, p *models.Post) string {
+	posts := []*models.Post{p}
+	var extra []string
+	for _, nav := range p.Navigations() {
+		if nav.Prev != nil {
+			posts = append(posts, nav.Prev)
+		}
+		if nav.Next != nil {
+			posts = append(posts, nav.Next)
+		}
+		extra = append(extra, nav.Term.Name, strconv.Itoa(nav.Index), strconv.Itoa(nav.Total))
+	}
+	posts = append(posts, p.Translations...)
+	return outputDepsHash(tplHash, posts, extra...)
+}
+
+// siteGlobalHash returns the hash of data shared by all outputs, including links of processed assets,
+// any change of them makes all outputs rebuilt.
+func siteGlobalHash(s *SiteData, opt *Option, assetsHash string) string {
+	var buf bytes.Buffer
+	buf.Write(s.configData)
+	buf.WriteString(assetsHash)
+	fmt.Fprintf(&buf, "%s|%v|%v|%v|%+v", opt.OutputDir, opt.EnableDrafts, opt.EnableFuture, opt.IsLocalServer, *s.Render.GetConfig())
+	// shortcodes are expanded in contents of all outputs
+	buf.WriteString(s.Render.GetTemplatesHash(constants.ShortcodeTemplateDir))
+	writeSiteSummary(&buf, s)
+	for _, ls := range s.LanguageSites {
+		writeSiteSummary(&buf, ls)
+	}
+	return utils.MD5Bytes(buf.Bytes())
+}
+
+// writeSiteSummary writes summaries shared by many outputs, such as tags in sidebar.
+func writeSiteSummary(buf *bytes.Buffer, s *SiteData) {
+	fmt.Fprintf(buf, "|%s", s.LinkPrefix)
+	for _, t := range s.Tags {
+		fmt.Fprintf(buf, "|%s:%d", t.Tag.Name, t.Tag.PostCount)
+	}
+	for _, a := range s.Authors {
+		fmt.Fprintf(buf, "|%s:%s:%d", a.Author.Name, a.Author.Slug, a.Author.PostCount)
+	}
+	for _, terms := range s.Taxonomies {
+		fmt.Fprintf(buf, "|%+v", *terms.Taxonomy)
+		for _, t := range terms.Terms {
+			fmt.Fprintf(buf, "|%s:%d", t.Name, t.PostCount)
+		}
+	}
+}
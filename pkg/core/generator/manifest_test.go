@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"pugo/pkg/utils/zlog"
+	"strings"
+	"testing"
+)
+
+func writeTestSite(t *testing.T) string {
+	dir := t.TempDir()
+	for file, f := range testSite(t) {
+		writeTestFile(t, dir, file, string(f.Data))
+	}
+	return dir
+}
+
+func writeTestFile(t *testing.T, dir, file, data string) {
+	file = filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// buildChanged builds the site with opt and returns changed files relative to output directory.
+func buildChanged(t *testing.T, opt *Option) map[string]bool {
+	opt.Logger = zlog.Nop()
+	res, err := Build(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(map[string]bool)
+	for _, file := range res.ChangedFiles {
+		rel, err := filepath.Rel(res.OutputDir, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed[filepath.ToSlash(rel)] = true
+	}
+	return changed
+}
+
+func expectChanged(t *testing.T, step string, changed map[string]bool, files ...string) {
+	for _, file := range files {
+		if !changed[file] {
+			t.Fatalf("%s: %s should be changed, changed files: %v", step, file, changed)
+		}
+	}
+}
+
+func TestIncrementalBuild(t *testing.T) {
+	dir := writeTestSite(t)
+	outputFile := func(file string) string { return filepath.Join(dir, "build", file) }
+
+	buildChanged(t, &Option{RootDir: dir})
+	if changed := buildChanged(t, &Option{RootDir: dir}); len(changed) != 0 {
+		t.Fatalf("unchanged site should write nothing, changed files: %v", changed)
+	}
+
+	writeTestFile(t, dir, "content/posts/hello.md", "---\ntitle: Hello World\nslug: hello\ntags: [go]\ndate: 2022-02-01 10:00:00\n---\nhello\n")
+	changed := buildChanged(t, &Option{RootDir: dir})
+	expectChanged(t, "edit post", changed,
+		"2022/02/hello/index.html", "index.html", "archives/index.html", "tag/go/index.html", "atom.xml", "sitemap.xml")
+	if changed["404.html"] || changed["static/js/main.js"] {
+		t.Fatalf("edit post should not write unrelated files, changed files: %v", changed)
+	}
+
+	post, err := os.ReadFile(filepath.Join(dir, "themes/default/post.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "themes/default/post.html", strings.Replace(string(post), "</body>", "<p>edited template</p></body>", 1))
+	changed = buildChanged(t, &Option{RootDir: dir})
+	expectChanged(t, "edit template", changed, "2022/02/hello/index.html")
+	data, err := os.ReadFile(outputFile("2022/02/hello/index.html"))
+	if err != nil || !strings.Contains(string(data), "edited template") {
+		t.Fatalf("edit template should render post with new template: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "content/pages/about.md")); err != nil {
+		t.Fatal(err)
+	}
+	changed = buildChanged(t, &Option{RootDir: dir})
+	expectChanged(t, "delete page", changed, "about/index.html", "sitemap.xml")
+	if _, err := os.Stat(outputFile("about/index.html")); !os.IsNotExist(err) {
+		t.Fatalf("output of deleted page should be removed: %v", err)
+	}
+
+	changed = buildChanged(t, &Option{RootDir: dir, DisableCache: true})
+	expectChanged(t, "no cache", changed, "2022/02/hello/index.html", "index.html", "404.html", "atom.xml", "static/js/main.js")
+}
+
+func TestIncrementalBuildAssets(t *testing.T) {
+	dir := writeTestSite(t)
+	outputFile := func(file string) string { return filepath.Join(dir, "build", file) }
+
+	buildChanged(t, &Option{RootDir: dir})
+	if _, err := os.Stat(outputFile("static/css/style.css")); err != nil {
+		t.Fatalf("style.css should be copied: %v", err)
+	}
+
+	config, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "config.toml", string(config)+"[build.assets]\n  enabled = true\n  fingerprint = true\n  minify = true\n")
+	changed := buildChanged(t, &Option{RootDir: dir})
+	expectChanged(t, "fingerprint", changed, "static/css/style.css", "index.html")
+	if _, err := os.Stat(outputFile("static/css/style.css")); !os.IsNotExist(err) {
+		t.Fatalf("fingerprinted source should not be copied: %v", err)
+	}
+	matches, _ := filepath.Glob(outputFile("static/css/style.*.css"))
+	if len(matches) != 1 {
+		t.Fatalf("fingerprinted style.css should be written: %v", matches)
+	}
+}
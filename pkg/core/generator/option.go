@@ -1,13 +1,102 @@
 package generator
 
-import "pugo/pkg/core/constants"
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/core/output"
+	"pugo/pkg/utils/zlog"
+	"time"
+)
 
 // Options is the options for building a site.
 type Option struct {
-	ConfigFileItem *constants.ConfigFileItem
-	OutputDir      string
-	EnableWatch    bool // if true, watch source files and rebuild when changed
-	EnableDrafts   bool // if true, render drafts
-	IsLocalServer  bool // if true, some template should be ignored, such as googleAnalytics
-	BuildArchive   bool // if true, build archive
+	ConfigFileItem *constants.ConfigFileItem // find in site root if nil
+	RootDir        string                    // site root directory, current directory if empty
+	OutputDir      string                    // relative to site root, use build config if empty
+	EnableWatch    bool                      // if true, watch source files and rebuild when changed
+	EnableDrafts   bool                      // if true, render drafts
+	EnableFuture   bool                      // if true, render posts dated in the future
+	IsLocalServer  bool                      // if true, some template should be ignored, such as googleAnalytics
+	BuildArchive   bool                      // if true, build archive
+	DisableCache   bool                      // if true, ignore the manifest of last build and rebuild all files
+	Jobs           int                       // number of parallel render workers, use cpu number if zero
+	ValidateFeed   bool                      // if true, check generated feeds and fail the build if invalid
+	Strict         bool                      // if true, fail the build on warnings in report
+	CheckLinks     bool                      // if true, check links and assets in rendered html, cache is not used
+	CheckExternal  bool                      // if true, also request external links in checking links
+
+	// SourceFS is read for config, contents, theme and assets instead of RootDir, such as an in-memory filesystem,
+	// output and cache files are still in RootDir and watching is not supported.
+	// Build cache and image cache are disabled if RootDir is empty, sites from filesystems do not share them.
+	SourceFS fs.FS
+
+	// Output writes outputs and assets instead of OutputDir, such as output.Memory,
+	// build cache and archive are disabled with it
+	Output output.Writer
+
+	// Logger receives logs of the build, use the global logger if nil
+	Logger zlog.Logger
+
+	// Clock returns the time to decide scheduled and expired posts, use time.Now if nil
+	Clock func() time.Time
+
+	// MemoryOutput receives outputs and static dirs instead of writing them to output directory,
+	// output paths are relative to output directory
+	MemoryOutput func(outputs []*models.OutputFile, copyDirs []*models.CopyDir)
+
+	// OnGenerated is called after outputs of each successful generation are written with the changed files,
+	// it is not called if the generation fails, including errors in report or warnings in strict mode
+	OnGenerated func(changedFiles []string)
+
+	watcher      *siteWatcher      // watches the site after first build if EnableWatch
+	memoryStamps map[string]string // stamps of files in memory by last build, to find changed files
+}
+
+func (opt *Option) now() time.Time {
+	if opt.Clock != nil {
+		return opt.Clock()
+	}
+	return time.Now()
+}
+
+func (opt *Option) logger() zlog.Logger {
+	return zlog.OrStd(opt.Logger)
+}
+
+func (opt *Option) sourceFS() fs.FS {
+	if opt.SourceFS != nil {
+		return opt.SourceFS
+	}
+	if opt.RootDir == "" {
+		return os.DirFS(".")
+	}
+	return os.DirFS(opt.RootDir)
+}
+
+// hasCacheRoot returns true if build cache and image cache can be kept in site root.
+func (opt *Option) hasCacheRoot() bool {
+	return opt.SourceFS == nil || opt.RootDir != ""
+}
+
+// rootPath returns the path of file in site root directory.
+func (opt *Option) rootPath(file string) string {
+	if opt.RootDir == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(opt.RootDir, file)
+}
+
+// findConfigFile returns the first config file existing in source filesystem.
+func (opt *Option) findConfigFile() (*constants.ConfigFileItem, error) {
+	fsys := opt.sourceFS()
+	for _, item := range constants.ConfigFiles() {
+		if _, err := fs.Stat(fsys, item.File); err == nil {
+			item := item
+			return &item, nil
+		}
+	}
+	return nil, constants.ErrConfigFileNotFound
 }
@@ -1,15 +1,15 @@
 package generator
 
 import (
-	"bytes"
-	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"pugo/pkg/core/models"
 	"pugo/pkg/core/theme"
-	"pugo/pkg/ext/markdown"
 	"pugo/pkg/utils"
-	"pugo/pkg/utils/zlog"
+	"strings"
 	"time"
 
 	"github.com/mholt/archiver/v4"
@@ -17,105 +17,183 @@ import (
 
 // Output outputs contents to destination directory.
 func Output(s *SiteData, ctx *Context, opt *Option) error {
-	if err := updateThemeCopyDirs(s.Render, ctx); err != nil {
-		zlog.Warn("theme: failed to update copy dirs", "err", err)
-		return err
+	if opt.MemoryOutput != nil {
+		outputMemory(ctx, opt)
+		return nil
 	}
-	if err := outputFiles(s, ctx); err != nil {
+	if err := outputFiles(s, ctx, opt.Jobs); err != nil {
 		return err
 	}
 	if err := copyAssets(opt.OutputDir, ctx); err != nil {
 		return err
 	}
+	for _, file := range ctx.cache.removeStaleOutputs() {
+		ctx.recordChangedFile(file)
+	}
+	if err := ctx.cache.save(); err != nil {
+		ctx.log.Warnf("cache: failed to save manifest: %s", err)
+	}
 	// BuildArchive generates archive files.
-	if opt.BuildArchive {
-		if err := buildArchive(ctx); err != nil {
+	if opt.BuildArchive && opt.Output != nil {
+		ctx.log.Warnf("archive: skipped, outputs are not in output directory")
+	} else if opt.BuildArchive {
+		if err := buildArchive(ctx, opt); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func updateThemeCopyDirs(r *theme.Render, ctx *Context) error {
+func updateThemeCopyDirs(r *theme.Render, ctx *Context) {
 	staticDirs := r.GetStaticDirs()
 	themeDir := r.GetDir()
 	for _, dir := range staticDirs {
-		ctx.appendCopyDir(filepath.Join(themeDir, dir), dir)
+		ctx.appendCopyDir(path.Join(themeDir, filepath.ToSlash(dir)), dir)
 	}
-	return nil
 }
 
-func outputFiles(s *SiteData, ctx *Context) error {
-	var (
-		err   error
-		fpath string
-		buf   *bytes.Buffer
-	)
-
+func outputFiles(s *SiteData, ctx *Context, jobs int) error {
 	outputs := ctx.GetOutputs()
-	for _, outputFile := range outputs {
-		fpath = outputFile.Path
-		buf = outputFile.Buf
+	return runJobs(ctx.buildCtx, jobs, len(outputs), func(i int) error {
+		var (
+			err   error
+			fpath = outputs[i].Path
+			buf   = outputs[i].Buf
+		)
 
 		data := buf.Bytes()
 		dataLen := len(data)
-		if s.BuildConfig.EnableMinifyHTML {
-			data, err = markdown.MinifyHTML(data)
+		// feeds, sitemap and search index are not html
+		if s.BuildConfig.EnableMinifyHTML && filepath.Ext(fpath) == ".html" {
+			data, err = ctx.minifier.MinifyHTML(data)
 			if err != nil {
-				zlog.Warnf("output: failed to minify: %s, %s", fpath, err)
+				ctx.log.Warnf("output: failed to minify: %s, %s", fpath, err)
 				data = buf.Bytes()
 			} else {
-				zlog.Debugf("minified ok: %s, %d -> %d", fpath, dataLen, len(data))
+				ctx.log.Debugf("minified ok: %s, %d -> %d", fpath, dataLen, len(data))
 			}
 		}
-		if err = utils.WriteFile(fpath, data); err != nil {
-			zlog.Warnf("output: failed to write file: %s, %s", fpath, err)
-			continue
+		if ctx.cache.isOutputWritten(fpath, data) {
+			ctx.log.Debugf("output not changed: %s", fpath)
+			ctx.recordLinkFile(fpath, fpath)
+			return nil
+		}
+		if err = ctx.writeFile(fpath, data); err != nil {
+			ctx.report.Errorf("", 0, "output: failed to write file: %s, %s", fpath, err)
+			return nil
 		}
 		ctx.recordLinkFile(fpath, fpath)
+		ctx.recordChangedFile(fpath)
+		return nil
+	})
+}
+
+// outputMemory passes outputs to memory handler instead of writing to output directory.
+// Only outputs and static files different from last build in memory are recorded as changed.
+func outputMemory(ctx *Context, opt *Option) {
+	outputs := ctx.GetOutputs()
+	memOutputs := make([]*models.OutputFile, 0, len(outputs))
+	stamps := make(map[string]string, len(outputs))
+	for _, o := range outputs {
+		relPath, err := filepath.Rel(opt.OutputDir, o.Path)
+		if err != nil {
+			ctx.log.Warnf("output: failed to get relative path: %s, %s", o.Path, err)
+			continue
+		}
+		memOutputs = append(memOutputs, &models.OutputFile{Path: relPath, Link: o.Link, Buf: o.Buf})
+		ctx.recordLinkFile(o.Path, o.Path)
+		stamps[o.Path] = utils.MD5Bytes(o.Buf.Bytes())
 	}
-	return nil
+	// static files are served from copy dirs, only their stamps are compared
+	files, err := ctx.staticFiles()
+	if err != nil {
+		ctx.log.Warnf("output: failed to read static files: %s", err)
+	}
+	for link, file := range files {
+		dstPath := filepath.Join(opt.OutputDir, filepath.FromSlash(link))
+		if _, ok := stamps[dstPath]; ok {
+			continue
+		}
+		if info, err := fs.Stat(ctx.source, file); err == nil {
+			stamps[dstPath] = fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	for file, stamp := range stamps {
+		if opt.memoryStamps[file] != stamp {
+			ctx.recordChangedFile(file)
+		}
+	}
+	for file := range opt.memoryStamps {
+		if _, ok := stamps[file]; !ok {
+			ctx.recordChangedFile(file)
+		}
+	}
+	opt.memoryStamps = stamps
+	opt.MemoryOutput(memOutputs, ctx.copingDirs)
+	ctx.log.Infof("output: %d files kept in memory", len(memOutputs))
 }
 
+// copyAssets copies static assets in source filesystem to output directory.
 func copyAssets(outputDir string, ctx *Context) error {
 	for _, dirData := range ctx.copingDirs {
-		if !utils.IsDirExist(dirData.SrcDir) {
+		if !isFSDir(ctx.source, dirData.SrcDir) {
 			continue
 		}
-		err := filepath.Walk(dirData.SrcDir, func(path string, info os.FileInfo, err error) error {
+		err := fs.WalkDir(ctx.source, dirData.SrcDir, func(file string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
-			if info.IsDir() {
+			if err := ctx.canceled(); err != nil {
+				return err
+			}
+			if d.IsDir() {
 				return nil
 			}
-			if utils.IsTempFile(path) {
-				zlog.Debugf("skip temp file: %s", path)
+			if utils.IsTempFile(file) {
+				ctx.log.Debugf("skip temp file: %s", file)
 				return nil
 			}
-			relPath, err := filepath.Rel(dirData.SrcDir, path)
+			info, err := d.Info()
 			if err != nil {
+				return err
+			}
+			relPath := strings.TrimPrefix(file, dirData.SrcDir+"/")
+			if dirData.SrcDir == "." {
+				relPath = file
+			}
+			dstPath := filepath.Join(outputDir, dirData.DestDir, filepath.FromSlash(relPath))
+			// minified by asset pipeline
+			if _, ok := ctx.outputs.Load(dstPath); ok {
+				return nil
+			}
+			// fingerprinted or bundled by asset pipeline
+			if ctx.assets != nil && ctx.assets.Replaced(path.Join("/", filepath.ToSlash(dirData.DestDir), relPath)) {
+				ctx.log.Debugf("assets replaced by pipeline: %s", dstPath)
+				return nil
+			}
+			if ctx.cache.isAssetCopied(info, dstPath) {
+				ctx.log.Debugf("assets not changed: %s", dstPath)
+				ctx.recordLinkFile(dstPath, dstPath)
 				return nil
 			}
-			dstPath := filepath.Join(dirData.DestDir, relPath)
-			dstPath = filepath.Join(outputDir, dstPath)
-			if err := utils.CopyFile(path, dstPath); err != nil {
-				zlog.Warnf("failed to copy file: %s, %s", dstPath, err)
+			if err := ctx.copyFile(file, dstPath); err != nil {
+				ctx.log.Warnf("failed to copy file: %s, %s", dstPath, err)
 				return err
 			}
-			zlog.Infof("assets copied: %s", dstPath)
+			ctx.log.Infof("assets copied: %s", dstPath)
 			ctx.recordLinkFile(dstPath, dstPath)
+			ctx.recordChangedFile(dstPath)
 			return nil
 		})
 		if err != nil {
-			zlog.Warnf("failed to copy assets: %s, %s", dirData.SrcDir, err)
+			ctx.log.Warnf("failed to copy assets: %s, %s", dirData.SrcDir, err)
 			return err
 		}
 	}
 	return nil
 }
 
-func buildArchive(ctx *Context) error {
+func buildArchive(ctx *Context, opt *Option) error {
 	files := ctx.GetRecordFiles()
 	if len(files) == 0 {
 		return fmt.Errorf("no files to archive")
@@ -131,7 +209,7 @@ func buildArchive(ctx *Context) error {
 	}
 
 	// create the output file we'll write to
-	filename := time.Now().Format("build-2006-01-02.tar.gz")
+	filename := opt.rootPath(time.Now().Format("build-2006-01-02.tar.gz"))
 	out, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -146,12 +224,12 @@ func buildArchive(ctx *Context) error {
 	}
 
 	// create the archive
-	err = format.Archive(context.Background(), out, archiveFiles)
+	err = format.Archive(ctx.buildCtx, out, archiveFiles)
 	if err != nil {
 		return err
 	}
 
 	info, _ := os.Stat(filename)
-	zlog.Infof("archive created: %s, size: %d KB", filename, info.Size()/1024)
+	ctx.log.Infof("archive created: %s, size: %d KB", filename, info.Size()/1024)
 	return nil
 }
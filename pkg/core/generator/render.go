@@ -1,10 +1,10 @@
 package generator
 
 import (
+	"fmt"
+	"pugo/pkg/core/models"
 	"pugo/pkg/core/theme"
-	"pugo/pkg/ext/feed"
 	"pugo/pkg/ext/sitemap"
-	"pugo/pkg/utils/zlog"
 )
 
 type renderBaseParams struct {
@@ -13,25 +13,128 @@ type renderBaseParams struct {
 	OutputDir       string
 	SiteTitle       string
 	SiteDescription string
+	Jobs            int
+	LinkPrefix      string // link prefix of site language
+	SitemapImages   bool   // add images of posts and pages to sitemap
 }
 
 func newRenderBaseParams(siteData *SiteData, context *Context, opt *Option) renderBaseParams {
-	return renderBaseParams{
+	params := renderBaseParams{
 		Ctx:             context,
 		Render:          siteData.Render,
 		OutputDir:       opt.OutputDir,
 		SiteTitle:       siteData.SiteConfig.Title,
 		SiteDescription: siteData.SiteConfig.Description,
+		Jobs:            opt.Jobs,
+		LinkPrefix:      siteData.LinkPrefix,
 	}
+	if cfg := siteData.Config.Extension.Sitemap; cfg != nil && cfg.Enabled {
+		params.SitemapImages = cfg.Images
+	}
+	return params
+}
+
+// contentSitemapURL returns sitemap url of post or page, nil if it is excluded.
+func contentSitemapURL(params *renderBaseParams, p *models.Post) *sitemap.URL {
+	meta := p.Sitemap
+	if meta == nil {
+		meta = &models.SitemapMeta{}
+	}
+	if meta.Exclude {
+		return nil
+	}
+	t := p.Updated()
+	u := &sitemap.URL{
+		Loc:        p.Link,
+		LastMod:    &t,
+		Alternates: translationAlternates(p),
+	}
+	if freq := sitemap.ChangeFreq(meta.ChangeFreq); freq != "" {
+		if freq.Valid() {
+			u.ChangeFreq = freq
+		} else {
+			params.Ctx.report.Warnf(p.LocalFile(), 0, "invalid sitemap changefreq: %s", freq)
+		}
+	}
+	if pr := meta.Priority; pr != 0 {
+		if pr > 0 && pr <= 1 {
+			u.Priority = pr
+		} else {
+			params.Ctx.report.Warnf(p.LocalFile(), 0, "invalid sitemap priority: %v", pr)
+		}
+	}
+	// images are found in html, unchanged contents are converted too
+	if params.SitemapImages {
+		if err := params.Ctx.convertPost(p); err == nil {
+			u.Images = sitemap.ImagesFromHTML(p.Content())
+		}
+	}
+	return u
 }
 
 func Render(siteData *SiteData, context *Context, opt *Option) error {
+	// language sites render in own contexts, links of all sites are built first for translations
+	langContexts := make([]*Context, len(siteData.LanguageSites))
+	for i, ls := range siteData.LanguageSites {
+		if langContexts[i] = context.newLanguageContext(ls, opt); langContexts[i] == nil {
+			return fmt.Errorf("failed to create context of language: %s", ls.Language.Code)
+		}
+		buildContentLinks(ls, langContexts[i])
+	}
+	buildContentLinks(siteData, context)
+
+	if err := renderSite(siteData, context, opt); err != nil {
+		return err
+	}
+	for i, ls := range siteData.LanguageSites {
+		if err := renderSite(ls, langContexts[i], opt); err != nil {
+			context.log.Warnf("render language failed: %s, %v", ls.Language.Code, err)
+			return err
+		}
+		context.mergeOutputs(langContexts[i])
+	}
+
+	// aliases of all languages are checked with all outputs
+	if err := renderRedirects(siteData, context, opt); err != nil {
+		context.log.Warnf("render redirects failed: %v", err)
+		return err
+	}
+
+	// all contents are converted, images in them are processed
+	context.outputImages()
+
+	// render sitemap
+	if err := context.canceled(); err != nil {
+		return err
+	}
+	outputs, err := context.sitemap.Render(siteData.Config.Extension.Sitemap, opt.OutputDir)
+	if err != nil {
+		context.log.Warnf("render sitemap failed: %v", err)
+		return err
+	}
+	for _, out := range outputs {
+		context.SetOutput(out.Path, out.Link, out.Buf)
+		context.log.Infof("sitemap generated: %s", out.Path)
+	}
+	return nil
+}
+
+// renderSite renders all pages of site in one language.
+func renderSite(siteData *SiteData, context *Context, opt *Option) error {
 	renderBase := newRenderBaseParams(siteData, context, opt)
 	if err := renderPosts(&renderPostsParams{
 		renderBaseParams: renderBase,
 		Posts:            siteData.Posts,
 	}); err != nil {
-		zlog.Warnf("render posts failed: %v", err)
+		context.log.Warnf("render posts failed: %v", err)
+		return err
+	}
+	if err := renderPosts(&renderPostsParams{
+		renderBaseParams: renderBase,
+		Posts:            siteData.ExpiredPosts,
+		Unlisted:         true,
+	}); err != nil {
+		context.log.Warnf("render expired posts failed: %v", err)
 		return err
 	}
 	postListParams := &renderPostListsParams{
@@ -41,11 +144,11 @@ func Render(siteData *SiteData, context *Context, opt *Option) error {
 		PostPageLinkFormat: siteData.BuildConfig.PostPageLinkFormat,
 	}
 	if err := renderPostLists(postListParams); err != nil {
-		zlog.Warnf("render post lists failed: %v", err)
+		context.log.Warnf("render post lists failed: %v", err)
 		return err
 	}
 	if err := renderIndex(postListParams); err != nil {
-		zlog.Warnf("render index failed: %v", err)
+		context.log.Warnf("render index failed: %v", err)
 		return err
 	}
 	if err := renderTags(&renderTagsParams{
@@ -53,8 +156,26 @@ func Render(siteData *SiteData, context *Context, opt *Option) error {
 		Tags:              siteData.Tags,
 		PostPerPage:       siteData.BuildConfig.PostPerPage,
 		TagPageLinkFormat: siteData.BuildConfig.TagPageLinkFormat,
+		Feed:              siteData.Config.Extension.Feed,
+	}); err != nil {
+		context.log.Warnf("render tags failed: %v", err)
+		return err
+	}
+	if err := renderAuthors(&renderAuthorsParams{
+		renderBaseParams: renderBase,
+		Authors:          siteData.Authors,
+		Feed:             siteData.Config.Extension.Feed,
+		PostPerPage:      siteData.BuildConfig.PostPerPage,
 	}); err != nil {
-		zlog.Warnf("render tags failed: %v", err)
+		context.log.Warnf("render authors failed: %v", err)
+		return err
+	}
+	if err := renderTaxonomies(&renderTaxonomiesParams{
+		renderBaseParams: renderBase,
+		Taxonomies:       siteData.Taxonomies,
+		PostPerPage:      siteData.BuildConfig.PostPerPage,
+	}); err != nil {
+		context.log.Warnf("render taxonomies failed: %v", err)
 		return err
 	}
 	if err := renderArchives(&renderArchivesParams{
@@ -62,7 +183,7 @@ func Render(siteData *SiteData, context *Context, opt *Option) error {
 		Posts:            siteData.Posts,
 		ArchivesLink:     siteData.BuildConfig.ArchivesLink,
 	}); err != nil {
-		zlog.Warnf("render archives failed: %v", err)
+		context.log.Warnf("render archives failed: %v", err)
 		return err
 	}
 
@@ -70,7 +191,7 @@ func Render(siteData *SiteData, context *Context, opt *Option) error {
 		renderBaseParams: renderBase,
 		Pages:            siteData.Pages,
 	}); err != nil {
-		zlog.Warnf("render pages failed: %v", err)
+		context.log.Warnf("render pages failed: %v", err)
 		return err
 	}
 
@@ -78,38 +199,26 @@ func Render(siteData *SiteData, context *Context, opt *Option) error {
 		renderBaseParams: renderBase,
 		SiteTitle:        siteData.SiteConfig.Title,
 	}); err != nil {
-		zlog.Warnf("render error page failed: %v", err)
+		context.log.Warnf("render error page failed: %v", err)
 		return err
 	}
 
-	// render feed
-	out, err := feed.Render(&feed.RenderParams{
-		Posts:       siteData.Posts,
-		SiteBaseURL: siteData.SiteConfig.Base,
-		SiteTitle:   siteData.SiteConfig.Title,
-		OutputDir:   opt.OutputDir,
-		Config:      siteData.Config.Extension.Feed,
-	})
-	if err != nil {
-		zlog.Warnf("render feed failed: %v", err)
+	// feeds and search index convert posts not rendered yet
+	if err := context.canceled(); err != nil {
 		return err
 	}
-	if out != nil {
-		context.SetOutput(out.Path, out.Link, out.Buf)
-		zlog.Infof("atom feed generated: %s", out.Path)
-	}
 
-	// render sitemap
-	out, err = sitemap.Render(siteData.Config.Extension.Sitemap, opt.OutputDir)
-	if err != nil {
-		zlog.Warnf("render sitemap failed: %v", err)
+	// render feeds
+	if err := renderFeeds(siteData, context, opt); err != nil {
+		context.log.Warnf("render feed failed: %v", err)
 		return err
 	}
-	if out != nil {
-		context.SetOutput(out.Path, out.Link, out.Buf)
-		zlog.Infof("sitemap generated: %s", out.Path)
+
+	// render search index
+	if err := renderSearchIndex(siteData, context, opt); err != nil {
+		context.log.Warnf("render search index failed: %v", err)
+		return err
 	}
 
 	return nil
-
 }
@@ -7,7 +7,6 @@ import (
 	"pugo/pkg/core/models"
 	"pugo/pkg/ext/sitemap"
 	"pugo/pkg/utils"
-	"pugo/pkg/utils/zlog"
 )
 
 type renderArchivesParams struct {
@@ -17,6 +16,18 @@ type renderArchivesParams struct {
 }
 
 func renderArchives(params *renderArchivesParams) error {
+	dstFile := utils.FormatIndexHTML(params.ArchivesLink)
+	dstFile = filepath.Join(params.OutputDir, dstFile)
+	t := latestPostUpdated(params.Posts)
+	params.Ctx.addSitemapURLs(&sitemap.URL{Loc: params.ArchivesLink, LastMod: &t})
+
+	// skip unchanged archives
+	depsHash := outputDepsHash(params.Render.GetTemplateHash(constants.ArchivesTemplate), params.Posts, params.ArchivesLink)
+	if params.Ctx.isOutputFresh(dstFile, depsHash) {
+		params.Ctx.log.Debugf("archives not changed: %s", dstFile)
+		return nil
+	}
+
 	archives := models.NewArchives(params.Posts)
 	buf := bytes.NewBuffer(nil)
 	extData := map[string]interface{}{
@@ -28,17 +39,11 @@ func renderArchives(params *renderArchivesParams) error {
 	}
 	tplData := params.Ctx.createTemplateData(extData)
 	if err := params.Render.Execute(buf, constants.ArchivesTemplate, tplData); err != nil {
-		zlog.Warnf("failed to render archives: %s", err)
-		return err
+		params.Ctx.reportRenderError(params.Render, "", "archives", err)
+		return nil
 	}
-	dstFile := utils.FormatIndexHTML(params.ArchivesLink)
-	dstFile = filepath.Join(params.OutputDir, dstFile)
 	params.Ctx.SetOutput(dstFile, params.ArchivesLink, buf)
-	zlog.Infof("archives generated: %s", dstFile)
-
-	t := params.Posts[0].Date()
-
-	sitemap.Add(&sitemap.URL{Loc: params.ArchivesLink, LastMod: &t})
+	params.Ctx.log.Infof("archives generated: %s", dstFile)
 
 	return nil
 }
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/feed"
+	"pugo/pkg/ext/sitemap"
+	"strconv"
+	"strings"
+)
+
+type renderAuthorsParams struct {
+	renderBaseParams
+	Authors     []*models.AuthorPosts
+	PostPerPage int
+	Feed        *feed.Config
+}
+
+type renderAuthorTask struct {
+	authorData *models.AuthorPosts
+	pager      *models.Pager
+	page       int
+}
+
+func renderAuthors(params *renderAuthorsParams) error {
+	tplName := constants.PostListTemplate
+	tplHash := params.Render.GetTemplateHash(tplName)
+
+	// collect all author pages
+	var tasks []renderAuthorTask
+	for _, authorData := range params.Authors {
+		pager := models.NewPager(params.PostPerPage, len(authorData.Posts))
+		for i := 1; i <= pager.PageSize(); i++ {
+			tasks = append(tasks, renderAuthorTask{authorData: authorData, pager: pager, page: i})
+		}
+	}
+
+	// build author pages
+	urls := make([][]*sitemap.URL, len(tasks))
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, len(tasks), func(n int) error {
+		authorData, pager, i := tasks[n].authorData, tasks[n].pager, tasks[n].page
+		author := authorData.Author
+		total := pager.PageSize()
+		// pages are in the same directory as author index.html
+		linkFormat := strings.TrimSuffix(filepath.ToSlash(filepath.Dir(author.LocalFile)), "/") + "/{{.Page}}/"
+		pageItem := pager.Page(i, linkFormat)
+		dstFile := filepath.Join(params.OutputDir, pageItem.LocalFile)
+		indexFile := filepath.Join(params.OutputDir, author.LocalFile)
+		posts := models.PostsPageList(authorData.Posts, pageItem)
+		t := latestPostUpdated(posts)
+
+		urls[n] = []*sitemap.URL{{Loc: pageItem.Link, LastMod: &t}}
+		if i == 1 {
+			urls[n] = append(urls[n], &sitemap.URL{Loc: author.Slug, LastMod: &t})
+		}
+
+		// skip unchanged author page, author index.html is the same as first page
+		depsHash := outputDepsHash(tplHash, posts, author.Name, pageItem.Link, strconv.Itoa(total))
+		isFresh := params.Ctx.isOutputFresh(dstFile, depsHash)
+		if i == 1 {
+			isFresh = params.Ctx.isOutputFresh(indexFile, depsHash) && isFresh
+		}
+		if isFresh {
+			params.Ctx.log.Debugf("author page not changed: %s", dstFile)
+			return nil
+		}
+
+		buf := bytes.NewBuffer(nil)
+		params.Ctx.convertPosts(posts)
+		tplData := params.Ctx.createTemplateData(map[string]interface{}{
+			"posts":        posts,
+			"pager":        pageItem,
+			"author_posts": authorData,
+			"feeds":        params.Feed.AuthorLinks(author.Slug, params.Ctx.log),
+			"current": map[string]interface{}{
+				"Title":       author.Name + "-" + params.SiteTitle,
+				"Description": author.Name + " - " + params.SiteDescription,
+			},
+		})
+		if err := params.Render.Execute(buf, tplName, tplData); err != nil {
+			params.Ctx.reportRenderError(params.Render, "", fmt.Sprintf("author page: %s, %d", author.Name, i), err)
+			return nil
+		}
+		params.Ctx.SetOutput(dstFile, pageItem.Link, buf)
+		params.Ctx.log.Infof("author page generated: %s", dstFile)
+
+		// author index.html, copy the buffer as outputs may be minified concurrently
+		if i == 1 {
+			params.Ctx.SetOutput(indexFile, author.Slug, bytes.NewBuffer(append([]byte(nil), buf.Bytes()...)))
+			params.Ctx.log.Infof("author page generated: %s", indexFile)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range urls {
+		params.Ctx.addSitemapURLs(u...)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package generator
 import (
 	"bytes"
 	"path/filepath"
-	"pugo/pkg/utils/zlog"
 )
 
 type renderErrorPageParams struct {
@@ -13,6 +12,15 @@ type renderErrorPageParams struct {
 
 func renderErrorPage(params *renderErrorPageParams) error {
 	notFoundTpl := params.Render.GetTemplate("404")
+	link := params.LinkPrefix + "/404.html"
+	dstFile := filepath.Join(params.OutputDir, link)
+
+	// skip unchanged 404 page
+	if params.Ctx.isOutputFresh(dstFile, outputDepsHash(params.Render.GetTemplateHash(notFoundTpl), nil, link)) {
+		params.Ctx.log.Debugf("404 not changed: %s", dstFile)
+		return nil
+	}
+
 	tplData := params.Ctx.createTemplateData(map[string]interface{}{
 		"current": map[string]interface{}{
 			"Title": params.SiteTitle,
@@ -21,12 +29,10 @@ func renderErrorPage(params *renderErrorPageParams) error {
 
 	buf := bytes.NewBuffer(nil)
 	if err := params.Render.Execute(buf, notFoundTpl, tplData); err != nil {
-		zlog.Warn("failed to render 404", "err", err)
-		return err
+		params.Ctx.reportRenderError(params.Render, "", "404", err)
+		return nil
 	}
-	link := "/404.html"
-	dstFile := filepath.Join(params.OutputDir, link)
 	params.Ctx.SetOutput(dstFile, link, buf)
-	zlog.Infof("404 generated: %s", dstFile)
+	params.Ctx.log.Infof("404 generated: %s", dstFile)
 	return nil
 }
@@ -1,21 +1,91 @@
 package generator
 
 import (
-	"bytes"
-	"path/filepath"
-	"pugo/pkg/ext/sitemap"
-	"pugo/pkg/utils/zlog"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/ext/feed"
 )
 
-func renderSitemap(link, outputDir string, ctx *Context) error {
-	buf := bytes.NewBuffer(nil)
-	if err := sitemap.Write(buf); err != nil {
-		zlog.Warnf("failed to marshal sitemap: %s", err)
+func renderFeeds(siteData *SiteData, ctx *Context, opt *Option) error {
+	feedConfig := siteData.Config.Extension.Feed
+	if feedConfig == nil || !feedConfig.Enabled {
+		return nil
+	}
+	base := feed.RenderParams{
+		Config:          feedConfig,
+		SiteBaseURL:     siteData.SiteConfig.Base,
+		SiteTitle:       siteData.SiteConfig.Title,
+		SiteDescription: siteData.SiteConfig.Description,
+		HomeLink:        siteData.LinkPrefix + "/",
+		Author:          siteData.Config.Author[0],
+		Generator:       constants.AppName() + " " + constants.AppVersion(),
+		OutputDir:       opt.OutputDir,
+		Validate:        opt.ValidateFeed,
+		Logger:          ctx.log,
+	}
+	if siteData.Language != nil {
+		base.Language = siteData.Language.Code
+	}
+
+	// site feeds
+	params := base
+	params.Posts = siteData.Posts
+	if err := renderFeed(&params, ctx); err != nil {
 		return err
 	}
-	var dstFile = link
-	dstFile = filepath.Join(outputDir, dstFile)
-	ctx.SetOutput(dstFile, link, buf)
-	zlog.Infof("sitemap generated: %s", dstFile)
+
+	// tag feeds
+	for _, tagData := range siteData.Tags {
+		links := feedConfig.TagLinks(tagData.Tag.Link, ctx.log)
+		if len(links) == 0 {
+			break
+		}
+		params := base
+		params.Links = links
+		params.Posts = tagData.Posts
+		params.SiteTitle = tagData.Tag.Name + " - " + base.SiteTitle
+		params.HomeLink = tagData.Tag.Link
+		if err := renderFeed(&params, ctx); err != nil {
+			return err
+		}
+	}
+
+	// author feeds
+	for _, authorData := range siteData.Authors {
+		links := feedConfig.AuthorLinks(authorData.Author.Slug, ctx.log)
+		if len(links) == 0 {
+			break
+		}
+		params := base
+		params.Links = links
+		params.Posts = authorData.Posts
+		params.SiteTitle = authorData.Author.Name + " - " + base.SiteTitle
+		params.HomeLink = authorData.Author.Slug
+		params.Author = authorData.Author
+		if err := renderFeed(&params, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderFeed renders feeds of posts, only posts in feeds are converted.
+func renderFeed(params *feed.RenderParams, ctx *Context) error {
+	links := params.Links
+	if len(links) == 0 {
+		links = params.Config.Links()
+	}
+	if limit := feed.MaxLimitNums(links); len(params.Posts) > limit {
+		params.Posts = params.Posts[:limit]
+	}
+	ctx.convertPosts(params.Posts)
+
+	outputs, err := feed.Render(params)
+	if err != nil {
+		return err
+	}
+	for _, out := range outputs {
+		ctx.SetOutput(out.Path, out.Link, out.Buf)
+		ctx.log.Infof("feed generated: %s", out.Path)
+	}
 	return nil
 }
@@ -3,23 +3,33 @@ package generator
 import (
 	"bytes"
 	"path/filepath"
-	"pugo/pkg/utils/zlog"
+	"pugo/pkg/core/models"
+	"strconv"
 )
 
 func renderIndex(params *renderPostListsParams) error {
 	indexTpl := params.Render.GetIndexTemplate()
+	link := params.LinkPrefix + "/index.html"
+	dstFile := filepath.Join(params.OutputDir, link)
+
+	// skip unchanged index
+	pageItem := params.Pager.Page(1, params.PostPageLinkFormat)
+	posts := models.PostsPageList(params.Posts, pageItem)
+	depsHash := outputDepsHash(params.Render.GetTemplateHash(indexTpl), posts, link, strconv.Itoa(pageItem.Total))
+	if params.Ctx.isOutputFresh(dstFile, depsHash) {
+		params.Ctx.log.Debugf("index not changed: %s", dstFile)
+		return nil
+	}
 
 	// first page
 	tplData, _ := buildPostListTemplateData(params, 1)
 
 	buf := bytes.NewBuffer(nil)
 	if err := params.Render.Execute(buf, indexTpl, tplData); err != nil {
-		zlog.Warn("failed to render index", "err", err)
-		return err
+		params.Ctx.reportRenderError(params.Render, "", "index", err)
+		return nil
 	}
-	link := "/index.html"
-	dstFile := filepath.Join(params.OutputDir, link)
 	params.Ctx.SetOutput(dstFile, link, buf)
-	zlog.Infof("index generated: %s", dstFile)
+	params.Ctx.log.Infof("index generated: %s", dstFile)
 	return nil
 }
@@ -4,10 +4,8 @@ import (
 	"bytes"
 	"path/filepath"
 	"pugo/pkg/core/models"
-	"pugo/pkg/ext/markdown"
 	"pugo/pkg/ext/sitemap"
 	"pugo/pkg/utils"
-	"pugo/pkg/utils/zlog"
 	"strings"
 )
 
@@ -17,30 +15,35 @@ type renderPagesParams struct {
 }
 
 func renderPages(params *renderPagesParams) error {
-	var (
-		err        error
-		dstFile    string
-		buf        *bytes.Buffer
-		tplData    map[string]interface{}
-		descGetter = func(page *models.Page) string {
-			if page.Descripition != "" {
-				return page.Descripition
-			}
-			return params.SiteDescription
+	descGetter := func(page *models.Page) string {
+		if page.Descripition != "" {
+			return page.Descripition
 		}
-	)
+		return params.SiteDescription
+	}
+
 	// build each page
-	for _, pg := range params.Pages {
+	urls := make([]*sitemap.URL, len(params.Pages))
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, len(params.Pages), func(i int) error {
+		pg := params.Pages[i]
 		pg.Link = "/" + strings.TrimPrefix(pg.Slug, "/")
-		dstFile = utils.FormatIndexHTML(pg.Link)
+		dstFile := filepath.Join(params.OutputDir, utils.FormatIndexHTML(pg.Link))
+
+		// skip unchanged page
+		depsHash := outputDepsHash(params.Render.GetTemplateHash(pg.Template), append([]*models.Post{&pg.Post}, pg.Translations...))
+		if params.Ctx.isOutputFresh(dstFile, depsHash) {
+			urls[i] = contentSitemapURL(&params.renderBaseParams, &pg.Post)
+			params.Ctx.log.Debugf("page not changed: %s", dstFile)
+			return nil
+		}
 
 		// convert markdown to html
-		if err = pg.Convert(markdown.Get()); err != nil {
-			zlog.Warnf("failed to convert markdown page: %s, %s", pg.LocalFile(), err)
-			continue
+		if err := params.Ctx.convertPost(&pg.Post); err != nil {
+			params.Ctx.report.Errorf(pg.LocalFile(), 0, "failed to convert markdown: %s", err)
+			return nil
 		}
 
-		buf = bytes.NewBuffer(nil)
+		buf := bytes.NewBuffer(nil)
 		extData := map[string]interface{}{
 			"page": pg,
 			"current": map[string]interface{}{
@@ -48,18 +51,22 @@ func renderPages(params *renderPagesParams) error {
 				"Description": descGetter(pg),
 			},
 		}
-		tplData = params.Ctx.createTemplateData(extData)
-		if err = params.Render.Execute(buf, pg.Template, tplData); err != nil {
-			zlog.Warnf("failed to render page: %s, %s", pg.LocalFile(), err)
-			continue
+		tplData := params.Ctx.createTemplateData(extData)
+		if err := params.Render.Execute(buf, pg.Template, tplData); err != nil {
+			params.Ctx.reportRenderError(params.Render, pg.LocalFile(), "page", err)
+			return nil
 		}
-		dstFile = filepath.Join(params.OutputDir, dstFile)
+		params.Ctx.recordImages(dstFile, &pg.Post)
 		params.Ctx.SetOutput(dstFile, pg.Link, buf)
-		zlog.Infof("page generated: %s", dstFile)
+		params.Ctx.log.Infof("page generated: %s", dstFile)
 
-		t := pg.Date()
-		sitemap.Add(&sitemap.URL{Loc: pg.Link, LastMod: &t})
+		urls[i] = contentSitemapURL(&params.renderBaseParams, &pg.Post)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	params.Ctx.addSitemapURLs(urls...)
 
 	return nil
 }
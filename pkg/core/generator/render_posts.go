@@ -2,26 +2,24 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
 	"path/filepath"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/core/models"
-	"pugo/pkg/ext/markdown"
 	"pugo/pkg/ext/sitemap"
-	"pugo/pkg/utils/zlog"
+	"strconv"
 )
 
 type renderPostsParams struct {
 	renderBaseParams
-	Posts []*models.Post
+	Posts    []*models.Post
+	Unlisted bool // if true, posts are not added to sitemap
 }
 
 func renderPosts(params *renderPostsParams) error {
 	var (
-		err        error
-		dstFile    string
-		link       string
-		buf        *bytes.Buffer
-		tplData    map[string]interface{}
+		posts      []*models.Post
+		dstFiles   []string
 		descGetter = func(post *models.Post) string {
 			if post.Descripition != "" {
 				return post.Descripition
@@ -30,25 +28,38 @@ func renderPosts(params *renderPostsParams) error {
 		}
 	)
 
-	// build each post
+	// build links one by one before rendering, post lists need all links
 	for _, p := range params.Posts {
-
-		// build link
-		link, dstFile, err = params.Ctx.createPostLink(p)
+		link, dstFile, err := params.Ctx.createPostLink(p)
 		if err != nil {
-			zlog.Warnf("failed to build post link: %s, %s", p.LocalFile(), err)
+			params.Ctx.report.Errorf(p.LocalFile(), 0, "failed to build post link: %s", err)
 			continue
 		}
 		p.Link = link
-		dstFile = filepath.Join(params.OutputDir, dstFile)
+		posts = append(posts, p)
+		dstFiles = append(dstFiles, filepath.Join(params.OutputDir, dstFile))
+	}
+
+	// build each post
+	urls := make([]*sitemap.URL, len(posts))
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, len(posts), func(i int) error {
+		p, dstFile := posts[i], dstFiles[i]
+
+		// skip unchanged post
+		depsHash := postDepsHash(params.Render.GetTemplateHash(p.Template), p)
+		if params.Ctx.isOutputFresh(dstFile, depsHash) {
+			urls[i] = contentSitemapURL(&params.renderBaseParams, p)
+			params.Ctx.log.Debugf("post not changed: %s", dstFile)
+			return nil
+		}
 
 		// convert markdown
-		if err := p.Convert(markdown.Get()); err != nil {
-			zlog.Warnf("failed to convert markdown post: %s, %s", p.LocalFile(), err)
-			continue
+		if err := params.Ctx.convertPost(p); err != nil {
+			params.Ctx.report.Errorf(p.LocalFile(), 0, "failed to convert markdown: %s", err)
+			return nil
 		}
 
-		buf = bytes.NewBuffer(nil)
+		buf := bytes.NewBuffer(nil)
 		extData := map[string]interface{}{
 			"post": p,
 			"current": map[string]interface{}{
@@ -56,19 +67,25 @@ func renderPosts(params *renderPostsParams) error {
 				"Description": descGetter(p),
 			},
 		}
-		tplData = params.Ctx.createTemplateData(extData)
-		if err = params.Render.Execute(buf, p.Template, tplData); err != nil {
-			zlog.Debugf("failed to render post: %s, %s", p.LocalFile(), err)
-			continue
+		tplData := params.Ctx.createTemplateData(extData)
+		if err := params.Render.Execute(buf, p.Template, tplData); err != nil {
+			params.Ctx.reportRenderError(params.Render, p.LocalFile(), "post", err)
+			return nil
 		}
 
 		// save buffer to write content file later
+		params.Ctx.recordImages(dstFile, p)
 		params.Ctx.SetOutput(dstFile, p.Link, buf)
-		zlog.Infof("post generated: %s", dstFile)
-
-		t := p.Date()
-		sitemap.Add(&sitemap.URL{Loc: link, LastMod: &t})
+		params.Ctx.log.Infof("post generated: %s", dstFile)
 
+		urls[i] = contentSitemapURL(&params.renderBaseParams, p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !params.Unlisted {
+		params.Ctx.addSitemapURLs(urls...)
 	}
 
 	return nil
@@ -83,8 +100,10 @@ type renderPostListsParams struct {
 
 func buildPostListTemplateData(params *renderPostListsParams, page int) (map[string]interface{}, *models.PagerItem) {
 	pageItem := params.Pager.Page(page, params.PostPageLinkFormat)
+	posts := models.PostsPageList(params.Posts, pageItem)
+	params.Ctx.convertPosts(posts)
 	tplData := params.Ctx.createTemplateData(map[string]interface{}{
-		"posts": models.PostsPageList(params.Posts, pageItem),
+		"posts": posts,
 		"pager": pageItem,
 		"current": map[string]interface{}{
 			"Title":       params.SiteTitle,
@@ -97,18 +116,35 @@ func buildPostListTemplateData(params *renderPostListsParams, page int) (map[str
 func renderPostLists(params *renderPostListsParams) error {
 	total := params.Pager.PageSize()
 	tplName := constants.PostListTemplate
-	for i := 1; i <= total; i++ {
+	tplHash := params.Render.GetTemplateHash(tplName)
+	urls := make([]*sitemap.URL, total)
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, total, func(n int) error {
+		i := n + 1
+		pageItem := params.Pager.Page(i, params.PostPageLinkFormat)
+		dstFile := filepath.Join(params.OutputDir, pageItem.LocalFile)
+		urls[n] = &sitemap.URL{Loc: pageItem.Link}
+
+		// skip unchanged page list
+		posts := models.PostsPageList(params.Posts, pageItem)
+		if params.Ctx.isOutputFresh(dstFile, outputDepsHash(tplHash, posts, pageItem.Link, strconv.Itoa(total))) {
+			params.Ctx.log.Debugf("post list not changed: %s", dstFile)
+			return nil
+		}
+
 		// build each page list
 		buf := bytes.NewBuffer(nil)
-		tplData, pageItem := buildPostListTemplateData(params, i)
+		tplData, _ := buildPostListTemplateData(params, i)
 		if err := params.Render.Execute(buf, tplName, tplData); err != nil {
-			zlog.Warnf("failed to render post list: %d, %s", i, err)
-			return err
+			params.Ctx.reportRenderError(params.Render, "", fmt.Sprintf("post list: %d", i), err)
+			return nil
 		}
-		dstFile := filepath.Join(params.OutputDir, pageItem.LocalFile)
 		params.Ctx.SetOutput(dstFile, pageItem.Link, buf)
-		sitemap.Add(&sitemap.URL{Loc: pageItem.Link})
-		zlog.Infof("post list generated: %s", dstFile)
+		params.Ctx.log.Infof("post list generated: %s", dstFile)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	params.Ctx.addSitemapURLs(urls...)
 	return nil
 }
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"path/filepath"
+	"pugo/pkg/ext/redirect"
+)
+
+// renderRedirects renders redirects of config rules and aliases of contents in all languages,
+// it should run after all pages are rendered to find aliases conflicting with them.
+func renderRedirects(siteData *SiteData, ctx *Context, opt *Option) error {
+	cfg := siteData.Config.Extension.Redirect
+	if cfg == nil {
+		return nil
+	}
+
+	outputs := make(map[string]bool)
+	for _, o := range ctx.GetOutputs() {
+		outputs[o.Path] = true
+	}
+	for _, f := range ctx.GetRecordFiles() {
+		outputs[f.Path] = true
+	}
+
+	var (
+		redirects []*redirect.Redirect
+		sources   = make(map[string]string)
+	)
+	add := func(source, from, to string, status int) {
+		link := redirect.CleanLink(from)
+		if link == "" {
+			ctx.report.Errorf(source, 0, "invalid redirect from: %q, it should be a link in site", from)
+			return
+		}
+		if to == "" {
+			ctx.report.Errorf(source, 0, "redirect from %s has no target", link)
+			return
+		}
+		if prev, ok := sources[link]; ok {
+			ctx.report.Errorf(source, 0, "duplicate redirect from: %s, also in %s", link, prev)
+			return
+		}
+		if outputs[filepath.Join(opt.OutputDir, redirect.StubFile(link))] {
+			ctx.report.Errorf(source, 0, "redirect from existing page: %s", link)
+			return
+		}
+		sources[link] = source
+		redirects = append(redirects, &redirect.Redirect{From: link, To: to, Status: status})
+	}
+
+	for _, rule := range cfg.Rules {
+		add("", rule.From, rule.To, rule.Status)
+	}
+	for _, s := range append([]*SiteData{siteData}, siteData.LanguageSites...) {
+		contents := append(s.Posts[:len(s.Posts):len(s.Posts)], s.ExpiredPosts...)
+		for _, pg := range s.Pages {
+			contents = append(contents, &pg.Post)
+		}
+		for _, p := range contents {
+			for _, alias := range p.Aliases {
+				add(p.LocalFile(), alias, p.Link, 0)
+			}
+		}
+	}
+
+	files, err := redirect.Render(&redirect.RenderParams{
+		Config:      cfg,
+		Redirects:   redirects,
+		SiteBaseURL: siteData.SiteConfig.Base,
+		OutputDir:   opt.OutputDir,
+	})
+	if err != nil {
+		return err
+	}
+	for _, out := range files {
+		ctx.SetOutput(out.Path, out.Link, out.Buf)
+	}
+	if len(files) > 0 {
+		ctx.log.Infof("redirects generated: %d", len(redirects))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"pugo/pkg/ext/search"
+)
+
+func renderSearchIndex(siteData *SiteData, ctx *Context, opt *Option) error {
+	cfg := siteData.Config.Extension.Search
+	if cfg == nil || !cfg.Enabled {
+		ctx.log.Debugf("search index is disabled")
+		return nil
+	}
+
+	posts := siteData.Posts
+	if cfg.IncludePages {
+		for _, pg := range siteData.Pages {
+			posts = append(posts[:len(posts):len(posts)], &pg.Post)
+		}
+	}
+
+	// skip unchanged index, it needs all contents converted
+	depsHash := outputDepsHash(fmt.Sprintf("%+v", *cfg), posts)
+	files := []string{filepath.Join(opt.OutputDir, cfg.Link)}
+	for i := 1; i <= cfg.ShardsCount(len(posts)); i++ {
+		files = append(files, filepath.Join(opt.OutputDir, cfg.ShardLink(i)))
+	}
+	isFresh := true
+	for _, file := range files {
+		isFresh = ctx.isOutputFresh(file, depsHash) && isFresh
+	}
+	if isFresh {
+		ctx.log.Debugf("search index not changed: %s", files[0])
+		return nil
+	}
+
+	ctx.convertPosts(posts)
+	outputs, err := search.Render(&search.RenderParams{
+		Config:    cfg,
+		Posts:     siteData.Posts,
+		Pages:     siteData.Pages,
+		OutputDir: opt.OutputDir,
+	})
+	if err != nil {
+		return err
+	}
+	for _, out := range outputs {
+		ctx.SetOutput(out.Path, out.Link, out.Buf)
+		ctx.log.Infof("search index generated: %s", out.Path)
+	}
+	return nil
+}
@@ -2,11 +2,13 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
 	"path/filepath"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/core/models"
+	"pugo/pkg/ext/feed"
 	"pugo/pkg/ext/sitemap"
-	"pugo/pkg/utils/zlog"
+	"strconv"
 	"strings"
 )
 
@@ -15,54 +17,89 @@ type renderTagsParams struct {
 	Tags              []*models.TagPosts
 	PostPerPage       int
 	TagPageLinkFormat string
+	Feed              *feed.Config
+}
+
+type renderTagTask struct {
+	tagData *models.TagPosts
+	pager   *models.Pager
+	page    int
 }
 
 func renderTags(params *renderTagsParams) error {
 
 	tplName := constants.PostListTemplate
+	tplHash := params.Render.GetTemplateHash(tplName)
 
-	// build tag pages
+	// collect all tag pages
+	var tasks []renderTagTask
 	for _, tagData := range params.Tags {
 		pager := models.NewPager(params.PostPerPage, len(tagData.Posts))
+		for i := 1; i <= pager.PageSize(); i++ {
+			tasks = append(tasks, renderTagTask{tagData: tagData, pager: pager, page: i})
+		}
+	}
 
+	// build tag pages
+	urls := make([][]*sitemap.URL, len(tasks))
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, len(tasks), func(n int) error {
+		tagData, pager, i := tasks[n].tagData, tasks[n].pager, tasks[n].page
 		total := pager.PageSize()
-		for i := 1; i <= total; i++ {
-			linkFormat := strings.ReplaceAll(params.TagPageLinkFormat, "{{.Tag}}", tagData.Tag.Name)
-			pageItem := pager.Page(i, linkFormat)
-			dstFile := pageItem.LocalFile
+		linkFormat := strings.ReplaceAll(params.TagPageLinkFormat, "{{.Tag}}", tagData.Tag.Name)
+		pageItem := pager.Page(i, linkFormat)
+		dstFile := filepath.Join(params.OutputDir, pageItem.LocalFile)
+		indexFile := filepath.Join(params.OutputDir, tagData.Tag.LocalFile)
+		posts := models.PostsPageList(tagData.Posts, pageItem)
+		t := latestPostUpdated(posts)
 
-			buf := bytes.NewBuffer(nil)
-			posts := models.PostsPageList(tagData.Posts, pageItem)
-			tplData := params.Ctx.createTemplateData(map[string]interface{}{
-				"posts": posts,
-				"pager": pageItem,
-				"tag":   tagData.Tag,
-				"current": map[string]interface{}{
-					"Title":       tagData.Tag.Name + "-" + params.SiteTitle,
-					"Description": tagData.Tag.Name + " - " + params.SiteDescription,
-				},
-			})
+		urls[n] = []*sitemap.URL{{Loc: pageItem.Link, LastMod: &t}}
+		if i == 1 {
+			urls[n] = append(urls[n], &sitemap.URL{Loc: tagData.Tag.Link, LastMod: &t})
+		}
 
-			if err := params.Render.Execute(buf, tplName, tplData); err != nil {
-				zlog.Warnf("failed to render tag page: %s, %d, %s", tagData.Tag.Name, i, err)
-				return err
-			}
-			dstFile = filepath.Join(params.OutputDir, dstFile)
-			params.Ctx.SetOutput(dstFile, pageItem.Link, buf)
-			zlog.Infof("tag page generated: %s", dstFile)
+		// skip unchanged tag page, tag list index.html is the same as first page
+		depsHash := outputDepsHash(tplHash, posts, tagData.Tag.Name, pageItem.Link, strconv.Itoa(total))
+		isFresh := params.Ctx.isOutputFresh(dstFile, depsHash)
+		if i == 1 {
+			isFresh = params.Ctx.isOutputFresh(indexFile, depsHash) && isFresh
+		}
+		if isFresh {
+			params.Ctx.log.Debugf("tag page not changed: %s", dstFile)
+			return nil
+		}
 
-			t := posts[0].Date()
+		buf := bytes.NewBuffer(nil)
+		params.Ctx.convertPosts(posts)
+		tplData := params.Ctx.createTemplateData(map[string]interface{}{
+			"posts": posts,
+			"pager": pageItem,
+			"tag":   tagData.Tag,
+			"feeds": params.Feed.TagLinks(tagData.Tag.Link, params.Ctx.log),
+			"current": map[string]interface{}{
+				"Title":       tagData.Tag.Name + "-" + params.SiteTitle,
+				"Description": tagData.Tag.Name + " - " + params.SiteDescription,
+			},
+		})
 
-			sitemap.Add(&sitemap.URL{Loc: pageItem.Link, LastMod: &t})
+		if err := params.Render.Execute(buf, tplName, tplData); err != nil {
+			params.Ctx.reportRenderError(params.Render, "", fmt.Sprintf("tag page: %s, %d", tagData.Tag.Name, i), err)
+			return nil
+		}
+		params.Ctx.SetOutput(dstFile, pageItem.Link, buf)
+		params.Ctx.log.Infof("tag page generated: %s", dstFile)
 
-			// tag list index.html
-			if i == 1 {
-				dstFile = filepath.Join(params.OutputDir, tagData.Tag.LocalFile)
-				params.Ctx.SetOutput(dstFile, tagData.Tag.Link, buf)
-				sitemap.Add(&sitemap.URL{Loc: tagData.Tag.Link, LastMod: &t})
-				zlog.Infof("tag page generated: %s", dstFile)
-			}
+		// tag list index.html, copy the buffer as outputs may be minified concurrently
+		if i == 1 {
+			params.Ctx.SetOutput(indexFile, tagData.Tag.Link, bytes.NewBuffer(append([]byte(nil), buf.Bytes()...)))
+			params.Ctx.log.Infof("tag page generated: %s", indexFile)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range urls {
+		params.Ctx.addSitemapURLs(u...)
 	}
 
 	return nil
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/sitemap"
+	"pugo/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type renderTaxonomiesParams struct {
+	renderBaseParams
+	Taxonomies  []*models.TaxonomyTerms
+	PostPerPage int
+}
+
+type renderTermTask struct {
+	terms *models.TaxonomyTerms
+	term  *models.TaxonomyTerm
+	pager *models.Pager
+	page  int
+}
+
+func renderTaxonomies(params *renderTaxonomiesParams) error {
+	tplName := constants.PostListTemplate
+	tplHash := params.Render.GetTemplateHash(tplName)
+
+	// collect all term pages
+	var tasks []renderTermTask
+	for _, terms := range params.Taxonomies {
+		for _, term := range terms.Terms {
+			pager := models.NewPager(params.PostPerPage, len(term.Posts))
+			for i := 1; i <= pager.PageSize(); i++ {
+				tasks = append(tasks, renderTermTask{terms: terms, term: term, pager: pager, page: i})
+			}
+		}
+	}
+
+	// build term pages
+	urls := make([][]*sitemap.URL, len(tasks))
+	err := runJobs(params.Ctx.buildCtx, params.Jobs, len(tasks), func(n int) error {
+		terms, term, pager, i := tasks[n].terms, tasks[n].term, tasks[n].pager, tasks[n].page
+		total := pager.PageSize()
+		linkFormat := strings.ReplaceAll(terms.Taxonomy.PageLinkFormat, "{{.Term}}", term.Name)
+		pageItem := pager.Page(i, linkFormat)
+		dstFile := filepath.Join(params.OutputDir, pageItem.LocalFile)
+		indexFile := filepath.Join(params.OutputDir, term.LocalFile)
+		posts := models.PostsPageList(term.Posts, pageItem)
+		t := latestPostUpdated(posts)
+
+		urls[n] = []*sitemap.URL{{Loc: pageItem.Link, LastMod: &t}}
+		if i == 1 {
+			urls[n] = append(urls[n], &sitemap.URL{Loc: term.Link, LastMod: &t})
+		}
+
+		// skip unchanged term page, term index.html is the same as first page
+		depsHash := outputDepsHash(tplHash, posts, terms.Taxonomy.Name, term.Name, pageItem.Link, strconv.Itoa(total))
+		isFresh := params.Ctx.isOutputFresh(dstFile, depsHash)
+		if i == 1 {
+			isFresh = params.Ctx.isOutputFresh(indexFile, depsHash) && isFresh
+		}
+		if isFresh {
+			params.Ctx.log.Debugf("%s page not changed: %s", terms.Taxonomy.Name, dstFile)
+			return nil
+		}
+
+		buf := bytes.NewBuffer(nil)
+		params.Ctx.convertPosts(posts)
+		tplData := params.Ctx.createTemplateData(map[string]interface{}{
+			"posts":    posts,
+			"pager":    pageItem,
+			"taxonomy": terms,
+			"term":     term,
+			"current": map[string]interface{}{
+				"Title":       term.Title + "-" + params.SiteTitle,
+				"Description": term.Title + " - " + params.SiteDescription,
+			},
+		})
+		if err := params.Render.Execute(buf, tplName, tplData); err != nil {
+			params.Ctx.reportRenderError(params.Render, "", fmt.Sprintf("%s page: %s, %d", terms.Taxonomy.Name, term.Name, i), err)
+			return nil
+		}
+		params.Ctx.SetOutput(dstFile, pageItem.Link, buf)
+		params.Ctx.log.Infof("%s page generated: %s", terms.Taxonomy.Name, dstFile)
+
+		// term index.html, copy the buffer as outputs may be minified concurrently
+		if i == 1 {
+			params.Ctx.SetOutput(indexFile, term.Link, bytes.NewBuffer(append([]byte(nil), buf.Bytes()...)))
+			params.Ctx.log.Infof("%s page generated: %s", terms.Taxonomy.Name, indexFile)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range urls {
+		params.Ctx.addSitemapURLs(u...)
+	}
+
+	// build taxonomy index pages
+	for _, terms := range params.Taxonomies {
+		if err := renderTaxonomyIndex(params, terms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderTaxonomyIndex(params *renderTaxonomiesParams, terms *models.TaxonomyTerms) error {
+	link := terms.Taxonomy.IndexLink
+	if link == "" || !terms.HasTerms() {
+		return nil
+	}
+	// themes without taxonomy template do not have index page
+	tplHash := params.Render.GetTemplateHash(constants.TaxonomyTemplate)
+	if tplHash == "" {
+		params.Ctx.log.Debugf("%s index skipped, template '%s' is missing", terms.Taxonomy.Name, constants.TaxonomyTemplate)
+		return nil
+	}
+	dstFile := filepath.Join(params.OutputDir, utils.FormatIndexHTML(link))
+
+	var (
+		posts []*models.Post
+		extra = []string{link}
+	)
+	for _, t := range terms.Terms {
+		posts = append(posts, t.Posts...)
+		extra = append(extra, t.Name)
+	}
+	lastMod := latestPostUpdated(posts)
+	params.Ctx.addSitemapURLs(&sitemap.URL{Loc: link, LastMod: &lastMod})
+
+	// skip unchanged index
+	if params.Ctx.isOutputFresh(dstFile, outputDepsHash(tplHash, posts, extra...)) {
+		params.Ctx.log.Debugf("%s index not changed: %s", terms.Taxonomy.Name, dstFile)
+		return nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tplData := params.Ctx.createTemplateData(map[string]interface{}{
+		"taxonomy": terms,
+		"terms":    terms.Roots,
+		"current": map[string]interface{}{
+			"Title":       terms.Taxonomy.Title + " - " + params.SiteTitle,
+			"Description": params.SiteDescription,
+		},
+	})
+	if err := params.Render.Execute(buf, constants.TaxonomyTemplate, tplData); err != nil {
+		params.Ctx.reportRenderError(params.Render, "", terms.Taxonomy.Name+" index", err)
+		return nil
+	}
+	params.Ctx.SetOutput(dstFile, link, buf)
+	params.Ctx.log.Infof("%s index generated: %s", terms.Taxonomy.Name, dstFile)
+	return nil
+}
+
+// latestPostUpdated returns the latest updated date of posts.
+func latestPostUpdated(posts []*models.Post) (t time.Time) {
+	for _, p := range posts {
+		if p.Updated().After(t) {
+			t = p.Updated()
+		}
+	}
+	return t
+}
@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"pugo/pkg/core/constants"
+	"pugo/pkg/core/theme"
+	"pugo/pkg/ext/shortcode"
+)
+
+// renderShortcode renders shortcodes by templates in theme, such as "shortcodes/youtube.html",
+// built-in shortcodes are used if no template is in theme.
+func renderShortcode(r *theme.Render) shortcode.Func {
+	builtins := shortcode.Builtins()
+	return func(sc *shortcode.Shortcode) (string, error) {
+		name := path.Join(constants.ShortcodeTemplateDir, sc.Name+".html")
+		if r.HasTemplate(name) {
+			var buf bytes.Buffer
+			if err := r.Execute(&buf, name, sc); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		}
+		if fn := builtins[sc.Name]; fn != nil {
+			return fn(sc)
+		}
+		return "", fmt.Errorf("unknown shortcode, add template %s in theme", name)
+	}
+}
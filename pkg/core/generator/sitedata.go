@@ -1,17 +1,23 @@
 package generator
 
 import (
+	"io/fs"
 	"pugo/pkg/core/configs"
 	"pugo/pkg/core/constants"
 	"pugo/pkg/core/models"
+	"pugo/pkg/core/report"
 	"pugo/pkg/core/theme"
 	"pugo/pkg/utils/zlog"
+	"time"
 )
 
 type SiteData struct {
-	Posts      []*models.Post
-	PostsPager *models.Pager
-	Tags       []*models.TagPosts
+	Posts        []*models.Post
+	ExpiredPosts []*models.Post // rendered, but not listed in pages, feeds and sitemap
+	PostsPager   *models.Pager
+	Tags         []*models.TagPosts
+	Taxonomies   []*models.TaxonomyTerms
+	Authors      []*models.AuthorPosts
 
 	Pages []*models.Page
 
@@ -21,6 +27,17 @@ type SiteData struct {
 	SiteConfig  *configs.Site
 
 	Render *theme.Render
+
+	Language      *configs.Language // current language, nil if site is not multilingual
+	LinkPrefix    string            // link prefix of current language
+	LanguageSites []*SiteData       // sites in other languages
+
+	// authors contains configured authors and authors only found in contents
+	authors []*models.Author
+	// configData is the raw data of config file
+	configData []byte
+	// report collects problems of config, contents and outputs
+	report *report.Report
 }
 
 // NewSiteData returns a new default sote data.
@@ -34,63 +51,113 @@ func NewSiteData() *SiteData {
 // SiteDataParams is the params for create site data.
 type SiteDataParams struct {
 	WithDrafts bool
+	WithFuture bool
+	Now        time.Time      // time to decide scheduled and expired posts
+	FS         fs.FS          // source filesystem of config and contents
+	Logger     zlog.Logger    // use the global logger if nil
+	Report     *report.Report // collects problems of config and contents, a new report is used if nil
 }
 
 // CreateSiteData creates a new site data from the given config.
 func CreateSiteData(item constants.ConfigFileItem, params *SiteDataParams) (*SiteData, error) {
 	siteData := NewSiteData()
+	log := zlog.OrStd(params.Logger)
+	params.Logger = log
+	if params.Report == nil {
+		params.Report = report.New(log)
+	}
 
-	zlog.Infof("load with drafts: %v", params.WithDrafts)
+	log.Infof("load with drafts: %v, future: %v, now: %s", params.WithDrafts, params.WithFuture, params.Now.Format(time.RFC3339))
 
 	// load config
-	cfg, err := configs.LoadFromFile(item)
+	data, err := fs.ReadFile(params.FS, item.File)
+	if err != nil {
+		params.Report.Errorf(item.File, 0, "load config file failed: %v", err)
+		return nil, err
+	}
+	cfg, err := configs.LoadFromBytes(data, item.Type)
 	if err != nil {
-		zlog.Warnf("load config file failed: %v", err)
+		params.Report.Errorf(item.File, 0, "load config file failed: %v", err)
 		return nil, err
 	}
-	zlog.Debugf("load config ok: %s", item.File)
+	log.Debugf("load config ok: %s", item.File)
+	siteData.configData = data
+	siteData.report = params.Report
 	siteData.ConfigType = item.Type
 	siteData.Config = cfg
 	siteData.BuildConfig = cfg.Build
 	siteData.SiteConfig = cfg.Site
 
 	// load theme
-	render, err := theme.NewRender(cfg.Theme)
+	render, err := theme.NewRender(params.FS, cfg.Theme, log)
 	if err != nil {
-		zlog.Warnf("load theme failed: %v", err)
+		params.Report.Errorf("", 0, "load theme failed: %v", err)
 		return nil, err
 	}
 	siteData.Render = render
 
-	// load contents
-	if siteData.Posts, err = models.LoadPosts(params.WithDrafts); err != nil {
-		zlog.Warnf("load posts failed: %v", err)
+	// load contents in site timezone
+	loc, err := cfg.Site.Location()
+	if err != nil {
+		params.Report.Errorf("", 0, "load site timezone failed: %v", err)
 		return nil, err
 	}
-	if siteData.Pages, err = models.LoadPages(params.WithDrafts); err != nil {
-		zlog.Warnf("load pages failed: %v", err)
+	log.Debugf("load site timezone ok: %s", loc)
+	posts, pages, err := loadContents(cfg, params, loc)
+	if err != nil {
+		params.Report.Errorf("", 0, "load contents failed: %v", err)
 		return nil, err
 	}
 
-	siteData.fullfill()
+	// split contents by languages
+	if len(cfg.Languages) > 0 {
+		return createLanguageSites(siteData, posts, pages, params), nil
+	}
+
+	siteData.Posts, siteData.ExpiredPosts = models.FilterScheduledPosts(posts, params.Now, params.WithFuture, log)
+	siteData.Pages = pages
+	siteData.fullfill(log)
 
 	return siteData, nil
 }
 
 // FulFill makes relative data available in source data
-func (s *SiteData) fullfill() {
+func (s *SiteData) fullfill(log zlog.Logger) {
+
+	// fix empty author slug
+	for _, author := range s.Config.Author {
+		if author.Slug == "" {
+			author.Slug = models.AuthorSlug(author.Name)
+		}
+	}
+	s.authors = append(s.authors, s.Config.Author...)
 
 	// set post author data
 	for _, post := range s.Posts {
-		post.Author = s.assignAuthor(post.AuthorName)
+		s.assignPostAuthors(post)
 		for _, t := range post.Tags {
 			post.TagLinks = append(post.TagLinks, &models.TagLink{Name: t})
 		}
 	}
+	// expired posts are not listed, so they have no tag links
+	for _, post := range s.ExpiredPosts {
+		s.assignPostAuthors(post)
+	}
 
 	// build tag posts
 	s.Tags = models.BuildTagPosts(s.Posts)
-	zlog.Infof("load tags ok: %d", len(s.Tags))
+	log.Infof("load tags ok: %d", len(s.Tags))
+
+	// build author posts
+	s.Authors = models.BuildAuthorPosts(s.Posts, s.authors)
+	log.Infof("load authors ok: %d", len(s.Authors))
+
+	// build taxonomy terms
+	for _, tx := range s.BuildConfig.Taxonomies {
+		terms := models.BuildTaxonomyTerms(s.Posts, tx)
+		s.Taxonomies = append(s.Taxonomies, terms)
+		log.Infof("load %s ok: %d", tx.Name, len(terms.Terms))
+	}
 
 	// set page author
 	for _, page := range s.Pages {
@@ -99,16 +166,32 @@ func (s *SiteData) fullfill() {
 
 	// set post pager data
 	s.PostsPager = models.NewPager(s.BuildConfig.PostPerPage, len(s.Posts))
-	zlog.Infof("load pagination ok: %d", s.PostsPager.PageSize())
+	log.Infof("load pagination ok: %d", s.PostsPager.PageSize())
+}
+
+func (s *SiteData) assignPostAuthors(post *models.Post) {
+	post.Author = s.assignAuthor(post.AuthorName)
+	for _, name := range post.CoAuthorName {
+		if name != "" {
+			post.CoAuthors = append(post.CoAuthors, s.assignAuthor(name))
+		}
+	}
 }
 
 func (s *SiteData) assignAuthor(name string) *models.Author {
 	if name == "" {
 		return s.Config.Author[0]
 	}
-	author := s.Config.GetAuthor(name)
-	if author == nil {
-		author = models.NewAuthor(name)
+	for _, author := range s.authors {
+		if author.Name == name || author.Slug == name {
+			return author
+		}
 	}
+	// unknown author is created once and shared by all contents,
+	// its demo email is not real and must not be shown in feeds
+	author := models.NewAuthor(name)
+	author.Email = ""
+	author.Slug = s.LinkPrefix + author.Slug
+	s.authors = append(s.authors, author)
 	return author
 }
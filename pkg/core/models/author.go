@@ -6,16 +6,27 @@ import (
 	"strings"
 )
 
-type Author struct {
-	Name        string            `toml:"name"`
-	Email       string            `toml:"email"`
-	Website     string            `toml:"website"`
-	Bio         string            `toml:"bio"`
-	Avatar      string            `toml:"avatar"`
-	UseGravatar bool              `toml:"use_gravatar"`
-	Slug        string            `toml:"slug"`
-	Social      map[string]string `toml:"social"`
-}
+type (
+	Author struct {
+		Name        string            `toml:"name"`
+		Email       string            `toml:"email"`
+		Website     string            `toml:"website"`
+		Bio         string            `toml:"bio"`
+		Avatar      string            `toml:"avatar"`
+		UseGravatar bool              `toml:"use_gravatar"`
+		Slug        string            `toml:"slug"`
+		Social      map[string]string `toml:"social"`
+
+		LocalFile string `toml:"-"`
+		PostCount int    `toml:"-"`
+	}
+
+	// AuthorPosts is posts written by an author, including co-authored posts.
+	AuthorPosts struct {
+		Author *Author
+		Posts  []*Post
+	}
+)
 
 // NewAuthor return a new author with demo fulfilled information.
 func NewAuthor(name string) *Author {
@@ -24,13 +35,18 @@ func NewAuthor(name string) *Author {
 		Email:       name + "@example.com",
 		Bio:         "user bio",
 		UseGravatar: true,
-		Slug:        "/author/" + url.PathEscape(name) + "/",
+		Slug:        AuthorSlug(name),
 		Social: map[string]string{
 			"github": "https://github.com/" + name,
 		},
 	}
 }
 
+// AuthorSlug returns the default slug of author by name.
+func AuthorSlug(name string) string {
+	return "/author/" + url.PathEscape(name) + "/"
+}
+
 // AvatarLink returns the avatar link
 func (a *Author) AvatarLink() string {
 	if !a.UseGravatar {
@@ -57,3 +73,25 @@ func (a *Author) GetSocial(key string) string {
 func (a *Author) Valid() bool {
 	return a.Name != ""
 }
+
+// BuildAuthorPosts returns the posts of each author in the order of authors.
+// Authors without posts are skipped.
+func BuildAuthorPosts(posts []*Post, authors []*Author) []*AuthorPosts {
+	authorData := make(map[*Author]*AuthorPosts)
+	for _, p := range posts {
+		for _, a := range p.Authors() {
+			if _, ok := authorData[a]; !ok {
+				authorData[a] = &AuthorPosts{Author: a}
+			}
+			authorData[a].Posts = append(authorData[a].Posts, p)
+		}
+	}
+	result := make([]*AuthorPosts, 0, len(authorData))
+	for _, a := range authors {
+		if data, ok := authorData[a]; ok {
+			a.PostCount = len(data.Posts)
+			result = append(result, data)
+		}
+	}
+	return result
+}
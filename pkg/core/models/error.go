@@ -0,0 +1,68 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// ContentError is the error of parsing a content file, Line is 0 if unknown.
+type ContentError struct {
+	Line int
+	Err  error
+	key  string // front-matter key with invalid value
+}
+
+func (e *ContentError) Error() string {
+	if e.key != "" {
+		return e.key + ": " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+func (e *ContentError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorLine returns the line of content error in file, 0 if unknown.
+func ErrorLine(err error) int {
+	var ce *ContentError
+	if errors.As(err, &ce) {
+		return ce.Line
+	}
+	return 0
+}
+
+var reErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// metaError returns the error with line in file,
+// yaml and toml errors have the line in front-matter, offset is the number of lines before it.
+func metaError(err error, offset int) error {
+	m := reErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	return &ContentError{Line: line + offset, Err: err}
+}
+
+// newContentError sets the line of invalid front-matter key in rawData.
+func newContentError(err error, rawData []byte) error {
+	var ce *ContentError
+	if !errors.As(err, &ce) || ce.Line > 0 || ce.key == "" {
+		return err
+	}
+	for i, line := range bytes.Split(rawData, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte(ce.key)) {
+			continue
+		}
+		rest := bytes.TrimSpace(line[len(ce.key):])
+		if bytes.HasPrefix(rest, []byte(":")) || bytes.HasPrefix(rest, []byte("=")) {
+			ce.Line = i + 1
+			break
+		}
+	}
+	return err
+}
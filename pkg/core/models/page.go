@@ -1,10 +1,10 @@
 package models
 
 import (
-	"os"
+	"io/fs"
 	"path/filepath"
 	"pugo/pkg/core/constants"
-	"pugo/pkg/utils/zlog"
+	"time"
 )
 
 // Page is the page model.
@@ -12,17 +12,17 @@ type Page struct {
 	Post
 }
 
-// NewPageFromFile creates a new page from file
-func NewPageFromFile(path, contentDir string) (*Page, error) {
+// NewPageFromFile creates a new page from file in fsys
+func NewPageFromFile(fsys fs.FS, file, contentDir string, loc *time.Location) (*Page, error) {
 	// parse basic info as post
-	p, err := parseContentBase(path)
+	p, err := parseContentBase(fsys, file, loc)
 	if err != nil {
 		return nil, err
 	}
 
 	// fix slug empty
 	if p.Slug == "" {
-		p.Slug, _ = filepath.Rel(contentDir, path)
+		p.Slug, _ = filepath.Rel(contentDir, file)
 	}
 
 	// fix empty template
@@ -35,11 +35,15 @@ func NewPageFromFile(path, contentDir string) (*Page, error) {
 	}, nil
 }
 
-func LoadPages(withDrafts bool) ([]*Page, error) {
+// LoadPages loads pages from directory in params.FS, such as content/pages.
+func LoadPages(dir string, params *LoadParams) ([]*Page, error) {
 	var pages []*Page
-	err := filepath.Walk(constants.ContentPagesDir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(params.FS, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		// skip directory
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
@@ -48,19 +52,19 @@ func LoadPages(withDrafts bool) ([]*Page, error) {
 			return nil
 		}
 
-		page, err := NewPageFromFile(path, constants.ContentPagesDir)
+		page, err := NewPageFromFile(params.FS, path, dir, params.Location)
 		if err != nil {
-			zlog.Warnf("failed to load page: %s, %s", path, err)
+			params.Report.Errorf(path, ErrorLine(err), "failed to load page: %s", err)
 			return nil
 		}
-		if page.Draft && !withDrafts {
-			zlog.Warnf("skip draft page: %s", path)
+		if page.Draft && !params.WithDrafts {
+			params.Logger.Warnf("skip draft page: %s", path)
 			return nil
 		}
 
 		// save post into parsed data
 		pages = append(pages, page)
-		zlog.Infof("load page ok: %s", path)
+		params.Logger.Infof("load page ok: %s", path)
 
 		return nil
 	})
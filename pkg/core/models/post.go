@@ -3,12 +3,16 @@ package models
 import (
 	"bytes"
 	"errors"
-	"io/ioutil"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
 	"pugo/pkg/core/constants"
+	"pugo/pkg/core/report"
 	"pugo/pkg/ext/markdown"
+	"pugo/pkg/ext/shortcode"
+	"pugo/pkg/ext/toc"
+	"pugo/pkg/utils"
 	"pugo/pkg/utils/zlog"
 	"sort"
 	"time"
@@ -19,31 +23,72 @@ import (
 
 // Post is the definition of a post.
 type Post struct {
-	Title        string   `toml:"title" yaml:"title"`
-	Slug         string   `toml:"slug" yaml:"slug"`
-	Descripition string   `toml:"description" yaml:"description"`
-	Tags         []string `toml:"tags" yaml:"tags"`
-	DateString   string   `toml:"date" yaml:"date"`
-	Template     string   `toml:"template" yaml:"template"`
-	Draft        bool     `toml:"draft" yaml:"draft"`
-	Comment      bool     `toml:"comment" yaml:"comment"`
-	AuthorName   string   `toml:"author" yaml:"author"`
-
-	Author   *Author    `toml:"-" yaml:"-"`
-	Link     string     `toml:"-" yaml:"-"`
-	TagLinks []*TagLink `toml:"-" yaml:"-"`
+	ID               string   `toml:"id,omitempty" yaml:"id,omitempty"` // stable id in feeds, the source file path if empty
+	Title            string   `toml:"title" yaml:"title"`
+	Slug             string   `toml:"slug" yaml:"slug"`
+	Descripition     string   `toml:"description" yaml:"description"`
+	Tags             []string `toml:"tags" yaml:"tags"`
+	DateString       string   `toml:"date" yaml:"date"`
+	UpdatedString    string   `toml:"updated,omitempty" yaml:"updated,omitempty"`
+	LastModString    string   `toml:"lastmod,omitempty" yaml:"lastmod,omitempty"` // alias of updated
+	Template         string   `toml:"template" yaml:"template"`
+	Draft            bool     `toml:"draft" yaml:"draft"`
+	Comment          bool     `toml:"comment" yaml:"comment"`
+	AuthorName       string   `toml:"author" yaml:"author"`
+	CoAuthorName     []string `toml:"co_authors,omitempty" yaml:"co_authors,omitempty"`
+	ExpiryDateString string   `toml:"expiry_date,omitempty" yaml:"expiry_date,omitempty"`
+	Lang             string   `toml:"lang,omitempty" yaml:"lang,omitempty"`
+	TranslationKey   string   `toml:"translation_key,omitempty" yaml:"translation_key,omitempty"`
+	Aliases          []string `toml:"aliases,omitempty" yaml:"aliases,omitempty"` // old links redirecting to this post
+	EnableTOC        *bool    `toml:"toc,omitempty" yaml:"toc,omitempty"`         // build table of contents if enabled in build config, enabled if not set
+
+	Sitemap *SitemapMeta `toml:"sitemap,omitempty" yaml:"sitemap,omitempty"` // nil if not set
+
+	Author    *Author                    `toml:"-" yaml:"-"`
+	CoAuthors []*Author                  `toml:"-" yaml:"-"`
+	Link      string                     `toml:"-" yaml:"-"`
+	TagLinks  []*TagLink                 `toml:"-" yaml:"-"`
+	TermLinks map[string][]*TaxonomyTerm `toml:"-" yaml:"-"`
+
+	Translations []*Post `toml:"-" yaml:"-"` // same post in other languages
 
 	localFile   string
+	modTime     time.Time
+	meta        map[string]interface{}
+	termNavs    map[string][]*TermNav
+	sourceHash  string
 	rawContent  []byte
 	htmlContent string
 	rawBrief    []byte
 	htmlBrief   string
+	headings    []*markdown.Heading
+	toc         *toc.TOC
 	dateTime    time.Time
+	updatedTime time.Time
+	expiryTime  time.Time
+	location    *time.Location
+}
+
+// SitemapMeta is the sitemap settings of a post or page in front-matter.
+type SitemapMeta struct {
+	Exclude    bool    `toml:"exclude" yaml:"exclude"`
+	ChangeFreq string  `toml:"changefreq" yaml:"changefreq"`
+	Priority   float32 `toml:"priority" yaml:"priority"`
+}
+
+// LoadParams is the params for loading posts and pages.
+type LoadParams struct {
+	FS         fs.FS // source filesystem, content paths are relative to it
+	WithDrafts bool
+	Location   *time.Location // location of dates without offset
+	Logger     zlog.Logger
+	Report     *report.Report // collects contents failed to load
 }
 
-// NewPostFromFile returns a new post from file.
-func NewPostFromFile(path string) (*Post, error) {
-	p, err := parseContentBase(path)
+// NewPostFromFile returns a new post from file in fsys.
+// Dates without offset are in the location loc.
+func NewPostFromFile(fsys fs.FS, path string, loc *time.Location) (*Post, error) {
+	p, err := parseContentBase(fsys, path, loc)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +107,12 @@ func NewPostFromFile(path string) (*Post, error) {
 	return p, nil
 }
 
-func parseContentBase(path string) (*Post, error) {
-	rawData, err := ioutil.ReadFile(path)
+func parseContentBase(fsys fs.FS, path string, loc *time.Location) (*Post, error) {
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	rawData, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		return nil, err
 	}
@@ -71,11 +120,14 @@ func parseContentBase(path string) (*Post, error) {
 	rawData = bytes.TrimSpace(rawData)
 	rawData = bytes.Replace(rawData, []byte("\r\n"), []byte("\n"), -1)
 	p := &Post{
-		Draft:   false,
-		Comment: true,
+		Draft:      false,
+		Comment:    true,
+		sourceHash: utils.MD5Bytes(rawData),
+		modTime:    info.ModTime(),
+		location:   loc,
 	}
 	if err = p.Parse(path, rawData); err != nil {
-		return nil, err
+		return nil, newContentError(err, rawData)
 	}
 	return p, nil
 }
@@ -95,10 +147,9 @@ func (p *Post) Parse(file string, rawData []byte) error {
 		return err
 	}
 
-	// parse brief content
-	seperator := constants.PostBriefSeperator()
-	if bytes.Contains(p.rawContent, seperator) {
-		p.rawBrief = bytes.SplitN(p.rawContent, seperator, 2)[0]
+	// parse brief content, shortcodes are not split
+	if idx := shortcode.BriefIndex(p.rawContent, constants.PostBriefSeperator()); idx >= 0 {
+		p.rawBrief = p.rawContent[:idx]
 	}
 
 	return nil
@@ -121,11 +172,134 @@ func (p *Post) Date() time.Time {
 	return p.dateTime
 }
 
+// WrittenDate returns the post date as written in front-matter, in its own offset or UTC if no offset.
+// It does not change with site timezone.
+func (p *Post) WrittenDate() time.Time {
+	for _, layout := range constants.PostDateLayouts() {
+		if dt, err := time.Parse(layout, p.DateString); err == nil {
+			return dt
+		}
+	}
+	return p.dateTime
+}
+
+// Updated returns the last modified date of the post, it is the post date if not set.
+func (p *Post) Updated() time.Time {
+	if p.updatedTime.IsZero() {
+		return p.dateTime
+	}
+	return p.updatedTime
+}
+
+// ExpiryDate returns the expiry date of the post, it is zero if not set.
+func (p *Post) ExpiryDate() time.Time {
+	return p.expiryTime
+}
+
+// IsFuture returns true if the post is scheduled after now.
+func (p *Post) IsFuture(now time.Time) bool {
+	return p.dateTime.After(now)
+}
+
+// IsExpired returns true if the post is expired before now.
+func (p *Post) IsExpired(now time.Time) bool {
+	return !p.expiryTime.IsZero() && !p.expiryTime.After(now)
+}
+
 // LocalFile returns the local file path of the post.
 func (p *Post) LocalFile() string {
 	return p.localFile
 }
 
+// SourceHash returns the hash of the post source file.
+func (p *Post) SourceHash() string {
+	return p.sourceHash
+}
+
+// Authors returns the author and all co-authors of the post.
+func (p *Post) Authors() []*Author {
+	var authors []*Author
+	if p.Author != nil && p.Author.Valid() {
+		authors = append(authors, p.Author)
+	}
+	for _, a := range p.CoAuthors {
+		if a != p.Author {
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
+
+// Meta returns the front-matter value by key.
+func (p *Post) Meta(key string) interface{} {
+	return p.meta[key]
+}
+
+// MetaInt returns the front-matter value by key as int, zero if not a number.
+func (p *Post) MetaInt(key string) int {
+	switch v := p.meta[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// Terms returns the terms of taxonomy from front-matter,
+// the value can be a string or a list of strings.
+func (p *Post) Terms(taxonomy string) []string {
+	switch v := p.meta[taxonomy].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		terms := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				terms = append(terms, s)
+			}
+		}
+		return terms
+	}
+	return nil
+}
+
+// Navigation returns the position of post in the first term of ordered taxonomy.
+func (p *Post) Navigation(taxonomy string) *TermNav {
+	if navs := p.termNavs[taxonomy]; len(navs) > 0 {
+		return navs[0]
+	}
+	return nil
+}
+
+// Navigations returns the positions of post in all ordered terms.
+func (p *Post) Navigations() []*TermNav {
+	var navs []*TermNav
+	for _, n := range p.termNavs {
+		navs = append(navs, n...)
+	}
+	sort.Slice(navs, func(i, j int) bool {
+		return navs[i].Term.Link < navs[j].Term.Link
+	})
+	return navs
+}
+
+func (p *Post) addTermLink(taxonomy string, t *TaxonomyTerm) {
+	if p.TermLinks == nil {
+		p.TermLinks = make(map[string][]*TaxonomyTerm)
+	}
+	p.TermLinks[taxonomy] = append(p.TermLinks[taxonomy], t)
+}
+
+func (p *Post) addTermNav(taxonomy string, nav *TermNav) {
+	if p.termNavs == nil {
+		p.termNavs = make(map[string][]*TermNav)
+	}
+	p.termNavs[taxonomy] = append(p.termNavs[taxonomy], nav)
+}
+
 func (p *Post) parseMeta(rawData []byte) ([]byte, error) {
 	separators := constants.PostMetaSeperators()
 	for _, seperator := range separators {
@@ -135,11 +309,16 @@ func (p *Post) parseMeta(rawData []byte) ([]byte, error) {
 		}
 		rawData = bytes.TrimPrefix(rawData, seperator.StartChars)
 		rawDataSlice := bytes.SplitN(rawData, seperator.EndChars, 2)
+		// lines before trimmed meta, error lines are relative to it
+		offset := 1 + bytes.Count(rawDataSlice[0], []byte("\n")) - bytes.Count(bytes.TrimLeft(rawDataSlice[0], " \t\r\n"), []byte("\n"))
 
 		// parse as toml
 		if seperator.MetaType == "toml" {
 			if err := toml.Unmarshal(bytes.TrimSpace(rawDataSlice[0]), p); err != nil {
-				return nil, err
+				return nil, metaError(err, offset)
+			}
+			if err := toml.Unmarshal(bytes.TrimSpace(rawDataSlice[0]), &p.meta); err != nil {
+				return nil, metaError(err, offset)
 			}
 			return bytes.TrimSpace(rawDataSlice[1]), nil
 		}
@@ -147,7 +326,10 @@ func (p *Post) parseMeta(rawData []byte) ([]byte, error) {
 		// parse as yaml
 		if seperator.MetaType == "yaml" {
 			if err := yaml.Unmarshal(bytes.TrimSpace(rawDataSlice[0]), p); err != nil {
-				return nil, err
+				return nil, metaError(err, offset)
+			}
+			if err := yaml.Unmarshal(bytes.TrimSpace(rawDataSlice[0]), &p.meta); err != nil {
+				return nil, metaError(err, offset)
 			}
 			return rawDataSlice[1], nil
 		}
@@ -156,21 +338,53 @@ func (p *Post) parseMeta(rawData []byte) ([]byte, error) {
 }
 
 func (p *Post) parseDate() error {
-	dateLayouts := constants.PostDateLayouts()
+	if p.location == nil {
+		p.location = time.UTC
+	}
 	// if date is empty, use file modified time
 	if p.DateString == "" {
-		info, _ := os.Stat(p.localFile)
-		p.DateString = info.ModTime().Format(dateLayouts[0])
+		if p.modTime.IsZero() {
+			if info, err := os.Stat(p.localFile); err == nil {
+				p.modTime = info.ModTime()
+			}
+		}
+		p.DateString = p.modTime.In(p.location).Format(constants.PostDateLayouts()[0])
+	}
+	dt, err := parseDateString(p.DateString, p.location)
+	if err != nil {
+		return &ContentError{key: "date", Err: err}
+	}
+	p.dateTime = dt
+
+	// updated and expiry date are optional
+	updated, updatedKey := p.UpdatedString, "updated"
+	if updated == "" {
+		updated, updatedKey = p.LastModString, "lastmod"
+	}
+	if updated != "" {
+		if p.updatedTime, err = parseDateString(updated, p.location); err != nil {
+			return &ContentError{key: updatedKey, Err: err}
+		}
 	}
-	for _, layout := range dateLayouts {
-		dt, err := time.Parse(layout, p.DateString)
+	if p.ExpiryDateString != "" {
+		if p.expiryTime, err = parseDateString(p.ExpiryDateString, p.location); err != nil {
+			return &ContentError{key: "expiry_date", Err: err}
+		}
+	}
+	return nil
+}
+
+// parseDateString parses date in location loc if it has no offset,
+// the result is always shown in location loc.
+func parseDateString(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range constants.PostDateLayouts() {
+		dt, err := time.ParseInLocation(layout, s, loc)
 		if err != nil {
 			continue
 		}
-		p.dateTime = dt
-		return nil
+		return dt.In(loc), nil
 	}
-	return constants.ErrInvalidPostDate
+	return time.Time{}, constants.ErrInvalidPostDate
 }
 
 // Convert converts post markdown content to html content.
@@ -180,25 +394,62 @@ func (p *Post) Convert(fn markdown.ConvertFunc) error {
 	}
 	buf := bytes.NewBuffer(nil)
 	if len(p.rawBrief) > 0 {
-		if err := fn(p.rawBrief, buf); err != nil {
+		if _, err := fn(p.rawBrief, buf); err != nil {
 			return err
 		}
 		p.htmlBrief = buf.String()
 		buf.Reset()
 	}
-	if err := fn(p.rawContent, buf); err != nil {
+	headings, err := fn(p.rawContent, buf)
+	if err != nil {
 		return err
 	}
 	p.htmlContent = buf.String()
+	p.headings = headings
 	return nil
 }
 
-// LoadPosts loads posts from content/posts directory.
-func LoadPosts(withDrafts bool) ([]*Post, error) {
+// BuildTOC builds table of contents from headings of converted content,
+// it is not built if disabled in cfg or front-matter.
+func (p *Post) BuildTOC(cfg *toc.Config) {
+	if cfg == nil || !cfg.Enabled || (p.EnableTOC != nil && !*p.EnableTOC) {
+		return
+	}
+	p.toc = toc.Build(p.headings, cfg)
+}
+
+// TOC returns table of contents of post, nil if it is not built or no heading is in it.
+func (p *Post) TOC() *toc.TOC {
+	return p.toc
+}
+
+// FilterScheduledPosts splits posts by publishing time.
+// Future posts are skipped unless withFuture is true, expired posts are returned separately.
+func FilterScheduledPosts(posts []*Post, now time.Time, withFuture bool, log zlog.Logger) (published, expired []*Post) {
+	for _, p := range posts {
+		if p.IsFuture(now) && !withFuture {
+			log.Warnf("skip future post: %s, %s", p.LocalFile(), p.DateString)
+			continue
+		}
+		if p.IsExpired(now) {
+			log.Infof("expired post: %s, %s", p.LocalFile(), p.ExpiryDateString)
+			expired = append(expired, p)
+			continue
+		}
+		published = append(published, p)
+	}
+	return published, expired
+}
+
+// LoadPosts loads posts from directory in params.FS, such as content/posts.
+func LoadPosts(dir string, params *LoadParams) ([]*Post, error) {
 	var posts []*Post
-	err := filepath.Walk(constants.ContentPostsDir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(params.FS, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		// skip directory
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
@@ -207,19 +458,19 @@ func LoadPosts(withDrafts bool) ([]*Post, error) {
 			return nil
 		}
 
-		post, err := NewPostFromFile(path)
+		post, err := NewPostFromFile(params.FS, path, params.Location)
 		if err != nil {
-			zlog.Warnf("failed to load post: %s, %s", path, err)
+			params.Report.Errorf(path, ErrorLine(err), "failed to load post: %s", err)
 			return nil
 		}
-		if post.Draft && !withDrafts {
-			zlog.Warnf("skip draft post: %s", path)
+		if post.Draft && !params.WithDrafts {
+			params.Logger.Warnf("skip draft post: %s", path)
 			return nil
 		}
 
 		// save post into parsed data
 		posts = append(posts, post)
-		zlog.Infof("load post ok: %s", path)
+		params.Logger.Infof("load post ok: %s", path)
 
 		return nil
 	})
@@ -227,9 +478,13 @@ func LoadPosts(withDrafts bool) ([]*Post, error) {
 	if err != nil {
 		return nil, err
 	}
+	SortPosts(posts)
+	return posts, nil
+}
+
+// SortPosts sorts posts by date desc.
+func SortPosts(posts []*Post) {
 	sort.Slice(posts, func(i, j int) bool {
-		// order by date desc
 		return posts[i].Date().Unix() > posts[j].Date().Unix()
 	})
-	return posts, nil
 }
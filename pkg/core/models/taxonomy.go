@@ -0,0 +1,157 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// Taxonomy is the configuration of a posts taxonomy, such as categories or series.
+	// Post terms are read from front-matter by the taxonomy name.
+	// Hierarchical terms are separated by "/", the post is also listed in parent terms.
+	// Posts in ordered terms are sorted by "<name>_order" front-matter and date ascending,
+	// each post gets previous and next navigation in the term.
+	Taxonomy struct {
+		Name           string `toml:"name"`
+		Title          string `toml:"title"`
+		Hierarchical   bool   `toml:"hierarchical"`
+		Ordered        bool   `toml:"ordered"`
+		LinkFormat     string `toml:"link_format"`
+		PageLinkFormat string `toml:"page_link_format"`
+		IndexLink      string `toml:"index_link"`
+	}
+
+	// TaxonomyTerm is a term of taxonomy with its posts.
+	TaxonomyTerm struct {
+		Name      string
+		Title     string
+		Level     int
+		Link      string
+		LocalFile string
+		PostCount int
+		Parent    *TaxonomyTerm
+		Children  []*TaxonomyTerm
+		Posts     []*Post
+	}
+
+	// TaxonomyTerms is all terms of a taxonomy.
+	TaxonomyTerms struct {
+		Taxonomy *Taxonomy
+		Terms    []*TaxonomyTerm
+		Roots    []*TaxonomyTerm
+	}
+
+	// TermNav is the position of a post in an ordered term.
+	TermNav struct {
+		Term  *TaxonomyTerm
+		Index int
+		Total int
+		Prev  *Post
+		Next  *Post
+	}
+)
+
+// HasTerms returns true if the taxonomy has any term.
+func (tt *TaxonomyTerms) HasTerms() bool {
+	return len(tt.Terms) > 0
+}
+
+// BuildTaxonomyTerms returns the terms of taxonomy with posts.
+func BuildTaxonomyTerms(posts []*Post, tx *Taxonomy) *TaxonomyTerms {
+	termsMap := make(map[string]*TaxonomyTerm)
+	getTerm := func(name string) *TaxonomyTerm {
+		if t, ok := termsMap[name]; ok {
+			return t
+		}
+		t := &TaxonomyTerm{
+			Name:  name,
+			Title: name,
+			Level: 1,
+		}
+		if tx.Hierarchical {
+			t.Level = strings.Count(name, "/") + 1
+			t.Title = name[strings.LastIndex(name, "/")+1:]
+		}
+		termsMap[name] = t
+		return t
+	}
+
+	for _, p := range posts {
+		for _, name := range p.Terms(tx.Name) {
+			name = strings.Trim(name, "/ ")
+			if name == "" {
+				continue
+			}
+			t := getTerm(name)
+			p.addTermLink(tx.Name, t)
+
+			// add post to the term and all parent terms
+			for {
+				if !containsPost(t.Posts, p) {
+					t.Posts = append(t.Posts, p)
+				}
+				if !tx.Hierarchical || t.Level == 1 {
+					break
+				}
+				parent := getTerm(t.Name[:strings.LastIndex(t.Name, "/")])
+				t.Parent = parent
+				t = parent
+			}
+		}
+	}
+
+	result := &TaxonomyTerms{Taxonomy: tx}
+	for _, t := range termsMap {
+		t.PostCount = len(t.Posts)
+		if tx.Ordered {
+			sortOrderedPosts(t.Posts, tx.Name)
+		}
+		result.Terms = append(result.Terms, t)
+	}
+	sort.Slice(result.Terms, func(i, j int) bool {
+		return result.Terms[i].Name < result.Terms[j].Name
+	})
+	for _, t := range result.Terms {
+		if t.Parent == nil {
+			result.Roots = append(result.Roots, t)
+		} else {
+			t.Parent.Children = append(t.Parent.Children, t)
+		}
+	}
+
+	if tx.Ordered {
+		for _, t := range result.Terms {
+			for i, p := range t.Posts {
+				nav := &TermNav{Term: t, Index: i + 1, Total: len(t.Posts)}
+				if i > 0 {
+					nav.Prev = t.Posts[i-1]
+				}
+				if i < len(t.Posts)-1 {
+					nav.Next = t.Posts[i+1]
+				}
+				p.addTermNav(tx.Name, nav)
+			}
+		}
+	}
+	return result
+}
+
+func containsPost(posts []*Post, p *Post) bool {
+	for _, post := range posts {
+		if post == p {
+			return true
+		}
+	}
+	return false
+}
+
+func sortOrderedPosts(posts []*Post, name string) {
+	key := name + "_order"
+	sort.SliceStable(posts, func(i, j int) bool {
+		oi, oj := posts[i].MetaInt(key), posts[j].MetaInt(key)
+		if oi != oj {
+			return oi < oj
+		}
+		return posts[i].Date().Before(posts[j].Date())
+	})
+}
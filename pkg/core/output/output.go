@@ -0,0 +1,80 @@
+// Package output provides the destinations of built files,
+// such as a local directory or memory.
+package output
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"pugo/pkg/utils"
+	"sort"
+	"sync"
+)
+
+// Writer writes built files, names are slash separated paths relative to output directory.
+type Writer interface {
+	WriteFile(name string, data []byte) error
+}
+
+// Dir writes files in a local directory.
+type Dir string
+
+// WriteFile writes data to the file in directory, parent directories are created if necessary.
+func (d Dir) WriteFile(name string, data []byte) error {
+	return utils.WriteFile(filepath.Join(string(d), filepath.FromSlash(name)), data)
+}
+
+// Discard drops all files, it is used to build without outputs, such as checking a site.
+var Discard Writer = discard{}
+
+type discard struct{}
+
+func (discard) WriteFile(name string, data []byte) error {
+	return nil
+}
+
+// Memory keeps files in memory, they are read by ReadFile and Files.
+type Memory struct {
+	lock  sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemory returns an empty memory output.
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string][]byte)}
+}
+
+// WriteFile keeps a copy of data as the file.
+func (m *Memory) WriteFile(name string, data []byte) error {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadFile returns the data of file.
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Files returns names of all files, sorted by name.
+func (m *Memory) Files() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+// Package report collects diagnostics of a build, such as contents failed to load or render.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"pugo/pkg/utils/zlog"
+	"sort"
+	"sync"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity string
+
+const (
+	// SeverityError means an output is missing or broken.
+	SeverityError Severity = "error"
+	// SeverityWarning means the build is fine but something should be fixed.
+	SeverityWarning Severity = "warning"
+)
+
+// ErrFailed means the build has errors, or warnings in strict mode.
+var ErrFailed = errors.New("build failed")
+
+// Diagnostic is a problem found in building.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"` // source file, relative to site root
+	Line     int      `json:"line,omitempty"` // 0 if unknown
+	Message  string   `json:"message"`
+}
+
+func (d *Diagnostic) String() string {
+	if d.File == "" {
+		return d.Message
+	}
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.File, d.Message)
+}
+
+// Report collects diagnostics, it is safe for concurrent use.
+type Report struct {
+	log         zlog.Logger
+	lock        sync.Mutex
+	diagnostics []*Diagnostic
+}
+
+// New returns an empty report, diagnostics are also logged to log.
+func New(log zlog.Logger) *Report {
+	return &Report{log: zlog.OrStd(log)}
+}
+
+// Add adds the diagnostic.
+func (r *Report) Add(d *Diagnostic) {
+	if d.Severity == SeverityError {
+		r.log.Errorf("%s", d)
+	} else {
+		r.log.Warnf("%s", d)
+	}
+	r.lock.Lock()
+	r.diagnostics = append(r.diagnostics, d)
+	r.lock.Unlock()
+}
+
+// Errorf adds an error of file at line.
+func (r *Report) Errorf(file string, line int, format string, args ...interface{}) {
+	r.Add(&Diagnostic{Severity: SeverityError, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// Warnf adds a warning of file at line.
+func (r *Report) Warnf(file string, line int, format string, args ...interface{}) {
+	r.Add(&Diagnostic{Severity: SeverityWarning, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// Diagnostics returns all diagnostics sorted by file and line.
+func (r *Report) Diagnostics() []*Diagnostic {
+	r.lock.Lock()
+	list := append([]*Diagnostic(nil), r.diagnostics...)
+	r.lock.Unlock()
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		return list[i].Line < list[j].Line
+	})
+	return list
+}
+
+// Count returns the number of diagnostics in severity.
+func (r *Report) Count(severity Severity) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	n := 0
+	for _, d := range r.diagnostics {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Err returns an error if there are errors, or warnings in strict mode.
+func (r *Report) Err(strict bool) error {
+	errs, warns := r.Count(SeverityError), r.Count(SeverityWarning)
+	if errs > 0 || (strict && warns > 0) {
+		return fmt.Errorf("%w with %d errors and %d warnings", ErrFailed, errs, warns)
+	}
+	return nil
+}
+
+// WriteJSON writes diagnostics to w in JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	list := r.Diagnostics()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(map[string]interface{}{
+		"errors":      r.Count(SeverityError),
+		"warnings":    r.Count(SeverityWarning),
+		"diagnostics": list,
+	})
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"pugo/pkg/utils/zlog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// LiveReloadPath is the path of live reload event stream.
+	LiveReloadPath = "/__pugo/livereload"
+
+	liveReloadMessage    = "reload"
+	liveReloadCSSMessage = "css"
+)
+
+// liveReloadScript connects to live reload event stream,
+// it reloads the page, or only refreshes stylesheets when css changed.
+var liveReloadScript = []byte(`<script>(function(){
+if(!window.EventSource){return;}
+var es=new EventSource("` + LiveReloadPath + `");
+es.onmessage=function(e){
+if(e.data==="` + liveReloadCSSMessage + `"){
+document.querySelectorAll('link[rel="stylesheet"]').forEach(function(l){
+var u=new URL(l.href);u.searchParams.set("_pugo",Date.now());l.href=u.toString();});
+return;}
+window.location.reload();};
+})();</script>`)
+
+// LiveReload notifies connected browsers to reload after site generated.
+type LiveReload struct {
+	lock    sync.Mutex
+	clients map[chan string]struct{}
+}
+
+// NewLiveReload returns a new live reload notifier.
+func NewLiveReload() *LiveReload {
+	return &LiveReload{
+		clients: make(map[chan string]struct{}),
+	}
+}
+
+// Notify sends reload message to all browsers by changed files.
+// If only css files changed, browsers refresh stylesheets without reloading page.
+func (l *LiveReload) Notify(changedFiles []string) {
+	if len(changedFiles) == 0 {
+		return
+	}
+	msg := liveReloadCSSMessage
+	for _, file := range changedFiles {
+		if filepath.Ext(file) != ".css" {
+			msg = liveReloadMessage
+			break
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.clients) == 0 {
+		return
+	}
+	for ch := range l.clients {
+		select {
+		case ch <- msg:
+		default:
+			// client is busy, it will get next message
+		}
+	}
+	zlog.Infof("live reload: %s, %d clients", msg, len(l.clients))
+}
+
+func (l *LiveReload) subscribe() chan string {
+	ch := make(chan string, 1)
+	l.lock.Lock()
+	l.clients[ch] = struct{}{}
+	l.lock.Unlock()
+	return ch
+}
+
+func (l *LiveReload) unsubscribe(ch chan string) {
+	l.lock.Lock()
+	delete(l.clients, ch)
+	l.lock.Unlock()
+}
+
+// ServeHTTP serves live reload event stream.
+func (l *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := l.subscribe()
+	defer l.unsubscribe(ch)
+	for {
+		select {
+		case msg := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// injectHandler injects live reload script into html responses of GET requests,
+// responses of other methods, such as HEAD, have no body to inject.
+func injectHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		iw := &injectWriter{ResponseWriter: w}
+		next.ServeHTTP(iw, r)
+		iw.flush()
+	})
+}
+
+type injectWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	isHTML      bool
+	wroteHeader bool
+}
+
+func (w *injectWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	// error pages are injected too, so they reload after the site is fixed
+	if hasBody(code) && strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		w.isHTML = true
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// hasBody returns true if the response of status code has a full body to inject.
+func hasBody(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent &&
+		code != http.StatusPartialContent && code != http.StatusNotModified
+}
+
+func (w *injectWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.isHTML {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *injectWriter) flush() {
+	if !w.isHTML {
+		return
+	}
+	data := w.buf.Bytes()
+	if len(data) == 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+	if idx := bytes.LastIndex(data, []byte("</body>")); idx >= 0 {
+		data = append(data[:idx:idx], append(liveReloadScript, data[idx:]...)...)
+	} else {
+		data = append(data, liveReloadScript...)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(data)
+}
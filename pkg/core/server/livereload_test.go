@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"pugo/pkg/core/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInjectNotFoundPage(t *testing.T) {
+	m := NewMemoryStore()
+	m.Update([]*models.OutputFile{
+		{Path: "404.html", Buf: bytes.NewBufferString("<html><body>not found</body></html>")},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	injectHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, LiveReloadPath) || !strings.HasSuffix(body, "</body></html>") {
+		t.Fatalf("live reload script should be injected into 404 page: %s", body)
+	}
+}
+
+func TestInjectOnlyGetHTML(t *testing.T) {
+	m := NewMemoryStore()
+	m.Update([]*models.OutputFile{
+		{Path: "index.html", Buf: bytes.NewBufferString("<html><body>home</body></html>")},
+		{Path: "data.json", Buf: bytes.NewBufferString(`{"body":"</body>"}`)},
+	}, nil)
+	serve := func(method, link string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		injectHandler(m).ServeHTTP(rec, httptest.NewRequest(method, link, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodHead, "/")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("HEAD should have no body: %d, %q", rec.Code, rec.Body.String())
+	}
+	if length := rec.Header().Get("Content-Length"); length != "" && length != "30" {
+		t.Fatalf("HEAD should have length of the page: %s", length)
+	}
+
+	if rec = serve(http.MethodGet, "/data.json"); strings.Contains(rec.Body.String(), LiveReloadPath) {
+		t.Fatalf("non-html response should not be injected: %s", rec.Body.String())
+	}
+	rec = serve(http.MethodGet, "/")
+	if !strings.Contains(rec.Body.String(), LiveReloadPath) || rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) {
+		t.Fatalf("html page should be injected with right length: %s, %s", rec.Header().Get("Content-Length"), rec.Body.String())
+	}
+}
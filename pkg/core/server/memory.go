@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps generated outputs in memory and serves them without writing to disk.
+type MemoryStore struct {
+	lock     sync.RWMutex
+	files    map[string][]byte
+	copyDirs []*models.CopyDir
+	modTime  time.Time
+}
+
+// NewMemoryStore returns a new empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		files: make(map[string][]byte),
+	}
+}
+
+// Update replaces all files in store by outputs and copy dirs of a new generation.
+// The output path is relative to output directory.
+func (m *MemoryStore) Update(outputs []*models.OutputFile, copyDirs []*models.CopyDir) {
+	files := make(map[string][]byte, len(outputs))
+	for _, o := range outputs {
+		files[path.Clean("/"+filepath.ToSlash(o.Path))] = o.Buf.Bytes()
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.files = files
+	m.copyDirs = copyDirs
+	m.modTime = time.Now()
+}
+
+// ServeHTTP serves files in store.
+func (m *MemoryStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if m.serve(w, r, name, http.StatusOK) {
+		return
+	}
+	// redirect directory to trailing slash
+	if !strings.HasSuffix(r.URL.Path, "/") && m.exist(path.Join(name, "index.html")) {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+	if m.serve(w, r, "/404.html", http.StatusNotFound) {
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (m *MemoryStore) exist(name string) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.files[name]
+	return ok || m.findCopyFile(name) != ""
+}
+
+func (m *MemoryStore) serve(w http.ResponseWriter, r *http.Request, name string, status int) bool {
+	m.lock.RLock()
+	data, ok := m.files[name]
+	srcFile := ""
+	if !ok {
+		srcFile = m.findCopyFile(name)
+	}
+	modTime := m.modTime
+	m.lock.RUnlock()
+
+	// outputs go first, static css and js processed by asset pipeline are in outputs
+	// and their source files are not copied
+	if !ok {
+		if srcFile == "" {
+			return false
+		}
+		http.ServeFile(w, r, srcFile)
+		return true
+	}
+	if status != http.StatusOK {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write(data)
+		return true
+	}
+	http.ServeContent(w, r, name, modTime, bytes.NewReader(data))
+	return true
+}
+
+// findCopyFile finds the source file of name in copy dirs.
+// The later dir has higher priority as it is copied later.
+func (m *MemoryStore) findCopyFile(name string) string {
+	for i := len(m.copyDirs) - 1; i >= 0; i-- {
+		dir := m.copyDirs[i]
+		rel, err := filepath.Rel(filepath.Clean("/"+dir.DestDir), filepath.FromSlash(name))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		srcFile := filepath.Join(dir.SrcDir, rel)
+		if utils.IsFileExist(srcFile) && !utils.IsDirExist(srcFile) && !utils.IsTempFile(srcFile) {
+			return srcFile
+		}
+	}
+	return ""
+}
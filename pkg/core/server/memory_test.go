@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/assets"
+	"testing"
+)
+
+func TestMemoryStoreServesAssetPipeline(t *testing.T) {
+	dir := t.TempDir()
+	source := "body {\n  color: red;\n}\n"
+	if err := os.MkdirAll(filepath.Join(dir, "static", "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "static", "css", "style.css"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &assets.Config{Enabled: true, Minify: true}
+	p, err := assets.Build(cfg, os.DirFS(dir), map[string]string{"/static/css/style.css": "static/css/style.css"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset, err := p.Get("/static/css/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.Link != "/static/css/style.css" || len(asset.Data) == 0 || string(asset.Data) == source {
+		t.Fatalf("asset should be minified at the same link: %s, %q", asset.Link, asset.Data)
+	}
+
+	m := NewMemoryStore()
+	m.Update([]*models.OutputFile{
+		{Path: "static/css/style.css", Link: asset.Link, Buf: bytes.NewBuffer(asset.Data)},
+	}, []*models.CopyDir{
+		{SrcDir: filepath.Join(dir, "static"), DestDir: "static"},
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/css/style.css", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if rec.Body.String() != string(asset.Data) {
+		t.Fatalf("should serve minified asset matching its integrity, got: %q", rec.Body.String())
+	}
+}
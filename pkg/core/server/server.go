@@ -12,8 +12,10 @@ type Server struct {
 }
 
 type ServerOption struct {
-	Port int
-	Dir  string
+	Port       int
+	Dir        string
+	LiveReload *LiveReload  // if not nil, inject live reload script into html pages
+	Memory     *MemoryStore // if not nil, serve files from memory instead of Dir
 }
 
 // New returns a new server.
@@ -25,7 +27,21 @@ func New(opt ServerOption) *Server {
 
 // Run runs the server.
 func (s *Server) Run() error {
-	http.Handle("/", http.FileServer(http.Dir(s.opt.Dir)))
-	zlog.Infof("listening on port %d, serving %s", s.opt.Port, s.opt.Dir)
-	return http.ListenAndServe(":"+fmt.Sprintf("%d", s.opt.Port), nil)
+	mux := http.NewServeMux()
+	var handler http.Handler = http.FileServer(http.Dir(s.opt.Dir))
+	if s.opt.Memory != nil {
+		handler = s.opt.Memory
+	}
+	if s.opt.LiveReload != nil {
+		mux.Handle(LiveReloadPath, s.opt.LiveReload)
+		handler = injectHandler(handler)
+		zlog.Infof("live reload enabled")
+	}
+	mux.Handle("/", handler)
+	if s.opt.Memory != nil {
+		zlog.Infof("listening on port %d, serving from memory", s.opt.Port)
+	} else {
+		zlog.Infof("listening on port %d, serving %s", s.opt.Port, s.opt.Dir)
+	}
+	return http.ListenAndServe(":"+fmt.Sprintf("%d", s.opt.Port), mux)
 }
@@ -1,5 +1,7 @@
 package theme
 
+import "pugo/pkg/ext/assets"
+
 // Config is the theme config.
 type Config struct {
 	Name             string   `toml:"name"`
@@ -9,6 +11,9 @@ type Config struct {
 	StaticDirs       []string `toml:"static_dirs"`
 	EnableDarkMode   bool     `toml:"enable_dark_mode"`
 	ShowPuGoVersion  bool     `toml:"show_pugo_version"`
+
+	// Bundles are css or js files concatenated by the asset pipeline, besides bundles in build config
+	Bundles []assets.Bundle `toml:"bundles"`
 }
 
 // NewDefaultConfig returns default theme config
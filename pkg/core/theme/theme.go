@@ -1,15 +1,19 @@
 package theme
 
 import (
+	"bytes"
 	"fmt"
 	"html/template"
 	"io"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
+	"pugo/pkg/ext/assets"
 	"pugo/pkg/utils"
 	"pugo/pkg/utils/zlog"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/BurntSushi/toml"
@@ -31,19 +35,26 @@ type namedTemplateFile struct {
 
 // Render renders the parsed data to static files.
 type Render struct {
+	fsys       fs.FS
+	log        zlog.Logger
 	dir        string
 	configFile string
 	config     *Config
 	funcMap    template.FuncMap
+	assets     *assets.Pipeline
 
 	lock      sync.Mutex
 	templates map[string]*template.Template
+	hashes    map[string]string
 	cache     []*namedTemplateFile
 }
 
-func NewRender(cfg *Theme) (*Render, error) {
+// NewRender creates a render with the theme in fsys.
+func NewRender(fsys fs.FS, cfg *Theme, log zlog.Logger) (*Render, error) {
 	r := &Render{
-		dir:        cfg.Directory,
+		fsys:       fsys,
+		log:        zlog.OrStd(log),
+		dir:        path.Clean(filepath.ToSlash(cfg.Directory)),
 		configFile: cfg.ConfigFile,
 		funcMap:    make(template.FuncMap),
 	}
@@ -61,6 +72,19 @@ func (r *Render) initDefaultFuncMap() {
 		}
 		return template.HTML(fmt.Sprintf("%v", v))
 	}
+	// asset resolves logical name of static file to its link and integrity
+	r.funcMap["asset"] = func(name string) (*assets.Asset, error) {
+		if r.assets == nil {
+			return &assets.Asset{Name: name, Link: name}, nil
+		}
+		return r.assets.Get(name)
+	}
+}
+
+// SetAssets sets the asset pipeline used by "asset" in templates, names are links as they are if nil.
+// It should be called before rendering.
+func (r *Render) SetAssets(p *assets.Pipeline) {
+	r.assets = p
 }
 
 // Parse parses theme config and template files.
@@ -81,16 +105,16 @@ func (r *Render) parseThemeConfig() error {
 	if r.configFile == "" {
 		return nil
 	}
-	configFile := filepath.Join(r.dir, r.configFile)
+	configFile := path.Join(r.dir, r.configFile)
 
-	fileBytes, err := ioutil.ReadFile(configFile)
+	fileBytes, err := fs.ReadFile(r.fsys, configFile)
 	if err != nil {
-		zlog.Warnf("failed to load theme config file: %s", configFile)
+		r.log.Warnf("failed to load theme config file: %s", configFile)
 		return err
 	}
 
 	if err = toml.Unmarshal(fileBytes, r.config); err != nil {
-		zlog.Warnf("failed to parse theme config file: %s", configFile)
+		r.log.Warnf("failed to parse theme config file: %s", configFile)
 		return err
 	}
 
@@ -102,32 +126,36 @@ func (r *Render) loadTemplates() error {
 	defer r.lock.Unlock()
 
 	templates := make(map[string]*template.Template, len(r.templates))
+	hashes := make(map[string]string, len(r.templates))
 	r.cache = make([]*namedTemplateFile, 0, len(r.templates))
 
-	err := filepath.Walk(r.dir, func(path string, fi os.FileInfo, err error) error {
-		if fi.IsDir() {
+	err := fs.WalkDir(r.fsys, r.dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
 			return nil
 		}
-		ext := filepath.Ext(path)
+		ext := path.Ext(file)
 		if !utils.Contains(r.config.Extension, ext) {
 			return nil
 		}
 
-		tpl, err := filepath.Rel(r.dir, path) // get relative path
-		if err != nil {
-			return err
-		}
-		if err := r.loadOneTemplate(path, tpl); err != nil {
-			zlog.Warnf("failed to load template: %s, %s", path, err)
+		tpl := r.relPath(file)
+		if err := r.loadOneTemplate(file, tpl); err != nil {
+			r.log.Warnf("failed to load template: %s, %s", file, err)
 			return err
 		}
 
 		var (
 			baseTmpl    *template.Template
 			currentTmpl *template.Template
+			hashBuf     bytes.Buffer
 		)
 
 		for i, nt := range r.cache {
+			hashBuf.WriteString(nt.Name)
+			hashBuf.WriteString(nt.Src)
 			if i == 0 {
 				baseTmpl = template.New(nt.Name)
 				currentTmpl = baseTmpl
@@ -141,8 +169,9 @@ func (r *Render) loadTemplates() error {
 			i++
 		}
 		templates[tpl] = baseTmpl
+		hashes[tpl] = utils.MD5Bytes(hashBuf.Bytes())
 
-		zlog.Debugf("load template ok: %s", path)
+		r.log.Debugf("load template ok: %s", file)
 
 		// release cache between twice render
 		r.cache = nil
@@ -151,11 +180,20 @@ func (r *Render) loadTemplates() error {
 	})
 
 	r.templates = templates
+	r.hashes = hashes
 
 	return err
 }
 
-func (r *Render) loadOneTemplate(path, rel string) error {
+// relPath returns the path of file relative to theme directory.
+func (r *Render) relPath(file string) string {
+	if r.dir == "." {
+		return file
+	}
+	return strings.TrimPrefix(file, r.dir+"/")
+}
+
+func (r *Render) loadOneTemplate(file, rel string) error {
 	// already loaded in cache
 	for _, t := range r.cache {
 		if t.Name == rel {
@@ -163,7 +201,7 @@ func (r *Render) loadOneTemplate(path, rel string) error {
 		}
 	}
 
-	fileBytes, err := ioutil.ReadFile(path)
+	fileBytes, err := fs.ReadFile(r.fsys, file)
 	if err != nil {
 		return err
 	}
@@ -178,11 +216,11 @@ func (r *Render) loadOneTemplate(path, rel string) error {
 	for _, raw := range reTemplateTag.FindAllString(tpl.Src, -1) {
 		parsed := reTemplateTag.FindStringSubmatch(raw)
 		tplPath := parsed[1]
-		tplExt := filepath.Ext(tplPath)
+		tplExt := path.Ext(tplPath)
 		if !utils.Contains(r.config.Extension, tplExt) {
 			continue
 		}
-		fullPath := filepath.Join(r.dir, tplPath)
+		fullPath := path.Join(r.dir, tplPath)
 		if err := r.loadOneTemplate(fullPath, tplPath); err != nil {
 			return err
 		}
@@ -207,6 +245,39 @@ func (r *Render) Execute(w io.Writer, name string, data interface{}) error {
 	return tpl.ExecuteTemplate(w, name, data)
 }
 
+// HasTemplate returns whether the template is in theme.
+func (r *Render) HasTemplate(name string) bool {
+	return r.getTemplate(name) != nil
+}
+
+// GetTemplatesHash gets the hash of all templates in dir of theme, such as templates of shortcodes.
+func (r *Render) GetTemplatesHash(dir string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var names []string
+	for name := range r.hashes {
+		if strings.HasPrefix(name, dir+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		buf.WriteString(name + r.hashes[name])
+	}
+	return utils.MD5Bytes(buf.Bytes())
+}
+
+// GetTemplateHash gets the hash of template by name,
+// it changes when the template or any sub-template included by it changes.
+func (r *Render) GetTemplateHash(name string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.hashes[name]
+}
+
 // GetIndexTemplate gets index template
 func (r *Render) GetIndexTemplate() string {
 	return r.GetTemplate("index")
@@ -223,7 +294,7 @@ func (r *Render) GetTemplate(name string) string {
 	return ""
 }
 
-// GetDir gets theme dir
+// GetDir gets theme dir in the source filesystem
 func (r *Render) GetDir() string {
 	return r.dir
 }
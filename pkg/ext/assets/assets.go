@@ -0,0 +1,213 @@
+// Package assets processes css and js files in static dirs,
+// files are minified, bundled and fingerprinted, and resolved with subresource integrity.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/js"
+)
+
+// Asset is a static file resolved by logical name.
+type Asset struct {
+	Name      string // logical name, the link of source file in site, such as "/static/css/style.css"
+	Link      string // link of output file, fingerprinted if enabled
+	Integrity string // subresource integrity, such as "sha384-...", empty if not processed
+	Data      []byte // processed data, nil if the source file is copied as it is
+}
+
+// String returns the link, so "{{asset "/static/css/style.css"}}" prints the link in templates.
+func (a *Asset) String() string {
+	return a.Link
+}
+
+// Pipeline keeps processed assets of one build.
+type Pipeline struct {
+	assets  map[string]*Asset
+	bundled map[string]string // logical names of files in bundles to bundle names
+	hash    string
+}
+
+// mediaTypes are types of files processed in pipeline.
+var mediaTypes = map[string]string{
+	".css": "text/css",
+	".js":  "application/javascript",
+}
+
+// Build processes css and js files and bundles in config,
+// files maps logical names of all static files to paths in fsys.
+func Build(cfg *Config, fsys fs.FS, files map[string]string) (*Pipeline, error) {
+	p := &Pipeline{assets: make(map[string]*Asset, len(files)), bundled: make(map[string]string)}
+	m := minify.New()
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("application/javascript", js.Minify)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sources := make(map[string][]byte)
+	for _, name := range names {
+		if _, ok := mediaTypes[path.Ext(name)]; !ok {
+			p.assets[name] = &Asset{Name: name, Link: name}
+			continue
+		}
+		data, err := fs.ReadFile(fsys, files[name])
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = data
+		if p.assets[name], err = process(cfg, m, name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range cfg.Bundles {
+		if _, ok := mediaTypes[path.Ext(b.Name)]; !ok {
+			return nil, fmt.Errorf("bundle %s should be css or js file", b.Name)
+		}
+		var buf bytes.Buffer
+		for _, name := range b.Files {
+			data, ok := sources[name]
+			if !ok {
+				return nil, fmt.Errorf("file of bundle %s is not found: %s", b.Name, name)
+			}
+			if path.Ext(name) == ".css" {
+				data = rebaseCSSURLs(data, path.Dir(name), path.Dir(b.Name))
+			}
+			buf.Write(data)
+			buf.WriteString("\n")
+			p.bundled[name] = b.Name
+		}
+		asset, err := process(cfg, m, b.Name, buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		// bundle is always written even not minified or fingerprinted
+		if asset.Data == nil {
+			asset.Data = buf.Bytes()
+		}
+		p.assets[b.Name] = asset
+	}
+
+	// files in bundles are only written in bundles
+	for name := range p.bundled {
+		delete(p.assets, name)
+	}
+
+	// only links and integrities of processed files are in outputs referring them
+	processed := make([]string, 0, len(sources)+len(cfg.Bundles))
+	for name, a := range p.assets {
+		if a.Integrity != "" {
+			processed = append(processed, name)
+		}
+	}
+	sort.Strings(processed)
+	h := sha256.New()
+	for _, name := range processed {
+		a := p.assets[name]
+		fmt.Fprintf(h, "%s|%s|%s\n", a.Name, a.Link, a.Integrity)
+	}
+	p.hash = hex.EncodeToString(h.Sum(nil))
+	return p, nil
+}
+
+// process minifies and fingerprints the file, Data is nil if it is not changed.
+func process(cfg *Config, m *minify.M, name string, data []byte) (*Asset, error) {
+	asset := &Asset{Name: name, Link: name}
+	if cfg.Minify {
+		minified, err := m.Bytes(mediaTypes[path.Ext(name)], data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to minify %s: %w", name, err)
+		}
+		data = minified
+		asset.Data = data
+	}
+	sum := sha512.Sum384(data)
+	asset.Integrity = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	if cfg.Fingerprint {
+		ext := path.Ext(name)
+		asset.Link = strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+		asset.Data = data
+	}
+	return asset, nil
+}
+
+// cssURLPattern matches url() in css, the quote is in group 1 and the url is in group 2.
+var cssURLPattern = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)['"]?\s*\)`)
+
+// rebaseCSSURLs rewrites relative urls in css of dir, so they work in css of newDir.
+func rebaseCSSURLs(data []byte, dir, newDir string) []byte {
+	if dir == newDir {
+		return data
+	}
+	return cssURLPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		sub := cssURLPattern.FindSubmatch(m)
+		link := strings.TrimSpace(string(sub[2]))
+		if u, err := url.Parse(link); err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(link, "/") || strings.HasPrefix(link, "#") {
+			return m
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(newDir), filepath.FromSlash(path.Join(dir, link)))
+		if err != nil {
+			return m
+		}
+		return []byte("url(" + string(sub[1]) + filepath.ToSlash(rel) + string(sub[1]) + ")")
+	})
+}
+
+// Get returns the asset by logical name, files in bundles are not resolved.
+func (p *Pipeline) Get(name string) (*Asset, error) {
+	if a, ok := p.assets[name]; ok {
+		return a, nil
+	}
+	if b, ok := p.bundled[name]; ok {
+		return nil, fmt.Errorf("asset %s is in bundle %s, use the bundle instead", name, b)
+	}
+	return nil, fmt.Errorf("asset is not found: %s", name)
+}
+
+// Replaced returns true if the source file of logical name should not be copied as it is,
+// it is bundled, or processed into a file with the same or a fingerprinted link.
+func (p *Pipeline) Replaced(name string) bool {
+	if _, ok := p.bundled[name]; ok {
+		return true
+	}
+	a, ok := p.assets[name]
+	return ok && a.Data != nil
+}
+
+// Assets returns assets to write, sorted by link.
+func (p *Pipeline) Assets() []*Asset {
+	var list []*Asset
+	for _, a := range p.assets {
+		if a.Data != nil {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Link < list[j].Link
+	})
+	return list
+}
+
+// Hash returns the hash of links and integrities of all processed assets,
+// it changes when any output referring assets should change.
+func (p *Pipeline) Hash() string {
+	return p.hash
+}
@@ -0,0 +1,115 @@
+package assets
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFiles() (fstest.MapFS, map[string]string) {
+	fsys := fstest.MapFS{
+		"static/css/style.css": {Data: []byte("body {\n  color: red;\n  background: url('../img/bg.png');\n}\n")},
+		"static/css/font.css":  {Data: []byte("@font-face {\n  src: url(fonts/a.woff2), url(data:font/woff2;base64,AA==), url(/static/b.woff);\n}\n")},
+		"static/js/main.js":    {Data: []byte("function hello ( name ) {\n  return 'hello ' + name;\n}\n")},
+		"static/img/bg.png":    {Data: []byte("png")},
+	}
+	files := make(map[string]string)
+	for file := range fsys {
+		files["/"+file] = file
+	}
+	return fsys, files
+}
+
+func TestBuildMinifyFingerprint(t *testing.T) {
+	fsys, files := testFiles()
+	p, err := Build(&Config{Minify: true, Fingerprint: true}, fsys, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := p.Get("/static/js/main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(a.Data), "\n") || len(a.Data) >= len(fsys["static/js/main.js"].Data) {
+		t.Fatalf("js should be minified: %q", a.Data)
+	}
+	sum := sha512.Sum384(a.Data)
+	if a.Integrity != "sha384-"+base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("integrity should be sha384 of processed data: %s", a.Integrity)
+	}
+	if !strings.HasPrefix(a.Link, "/static/js/main.") || !strings.HasSuffix(a.Link, ".js") || a.Link == a.Name {
+		t.Fatalf("link should be fingerprinted: %s", a.Link)
+	}
+	if !p.Replaced("/static/js/main.js") {
+		t.Fatal("fingerprinted source should not be copied")
+	}
+
+	img, err := p.Get("/static/img/bg.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Link != "/static/img/bg.png" || img.Integrity != "" || img.Data != nil || p.Replaced(img.Name) {
+		t.Fatalf("other files should be copied as they are: %+v", img)
+	}
+
+	// fingerprint changes with content
+	fsys["static/js/main.js"] = &fstest.MapFile{Data: []byte("console.log(1)")}
+	changed, err := Build(&Config{Minify: true, Fingerprint: true}, fsys, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := changed.Get("/static/js/main.js"); b.Link == a.Link || changed.Hash() == p.Hash() {
+		t.Fatalf("fingerprint should change with content: %s", b.Link)
+	}
+}
+
+func TestBuildUnprocessed(t *testing.T) {
+	fsys, files := testFiles()
+	p, err := Build(&Config{}, fsys, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := p.Get("/static/css/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Link != a.Name || a.Data != nil || a.Integrity == "" || p.Replaced(a.Name) {
+		t.Fatalf("source should be copied with integrity: %+v", a)
+	}
+	if len(p.Assets()) != 0 {
+		t.Fatalf("no assets should be written: %d", len(p.Assets()))
+	}
+}
+
+func TestBuildBundle(t *testing.T) {
+	fsys, files := testFiles()
+	cfg := &Config{Bundles: []*Bundle{{Name: "/static/bundle.css", Files: []string{"/static/css/style.css", "/static/css/font.css"}}}}
+	p, err := Build(cfg, fsys, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Get("/static/bundle.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// relative urls are rebased to directory of bundle
+	for _, s := range []string{"url('img/bg.png')", "url(css/fonts/a.woff2)", "url(data:font/woff2;base64,AA==)", "url(/static/b.woff)"} {
+		if !strings.Contains(string(b.Data), s) {
+			t.Fatalf("bundle should contain %s: %s", s, b.Data)
+		}
+	}
+	if _, err := p.Get("/static/css/style.css"); err == nil || !p.Replaced("/static/css/style.css") {
+		t.Fatal("files in bundle should not be resolved or copied")
+	}
+	if list := p.Assets(); len(list) != 1 || list[0] != b {
+		t.Fatalf("only bundle should be written: %v", list)
+	}
+
+	cfg.Bundles[0].Files = []string{"/static/css/missing.css"}
+	if _, err := Build(cfg, fsys, files); err == nil {
+		t.Fatal("bundle with missing file should fail")
+	}
+}
@@ -0,0 +1,24 @@
+package assets
+
+// Config is the config of asset pipeline.
+type Config struct {
+	Enabled     bool      `toml:"enabled"`     // process css and js files in static dirs, resolve them by "asset" in templates
+	Fingerprint bool      `toml:"fingerprint"` // add content hash to file names, such as "style.3f2a1c5d.css"
+	Minify      bool      `toml:"minify"`      // minify css and js files
+	Bundles     []*Bundle `toml:"bundles"`
+}
+
+// Bundle is a file concatenated by css or js files, the files are only written in the bundle,
+// relative urls in css files are rewritten for the directory of bundle.
+type Bundle struct {
+	Name  string   `toml:"name"`  // logical name, such as "/static/css/bundle.css"
+	Files []string `toml:"files"` // logical names of files in order, such as "/static/css/style.css"
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:     false,
+		Fingerprint: true,
+		Minify:      true,
+	}
+}
@@ -2,44 +2,47 @@ package ext
 
 import (
 	"pugo/pkg/core/configs"
-	"pugo/pkg/ext/markdown"
-	"pugo/pkg/ext/sitemap"
 	"pugo/pkg/utils/zlog"
 )
 
-func Reload(cfg *configs.Config) {
-	markdown.InitMinfier(cfg.Build.EnableMinifyHTML)
+// Reload logs the extensions enabled in cfg.
+func Reload(cfg *configs.Config, log zlog.Logger) {
 	if cfg.Build.EnableMinifyHTML {
-		zlog.Debugf("minify html: enabled")
+		log.Debugf("minify html: enabled")
 	}
 
 	ext := cfg.Extension
 	if ext.Feed != nil {
-		zlog.Debugf("feed reloaded, enabled:%v", ext.Feed.Enabled)
+		log.Debugf("feed reloaded, enabled:%v", ext.Feed.Enabled)
 	} else {
-		zlog.Debugf("feed reloaded, nil, disabled")
+		log.Debugf("feed reloaded, nil, disabled")
 	}
 
 	if ext.Sitemap != nil {
-		sitemap.Init(ext.Sitemap, cfg.Site.Base)
-		zlog.Debugf("sitemap reloaded, enabled:%v", ext.Sitemap.Enabled)
+		log.Debugf("sitemap reloaded, enabled:%v", ext.Sitemap.Enabled)
 	} else {
-		zlog.Debugf("sitemap reloaded, nil, disabled")
+		log.Debugf("sitemap reloaded, nil, disabled")
+	}
+
+	if ext.Search != nil {
+		log.Debugf("search index reloaded, enabled:%v", ext.Search.Enabled)
+	} else {
+		log.Debugf("search index reloaded, nil, disabled")
 	}
 
 	as := ext.Analytics
 	if as.GoogleAnalytics.Enabled {
-		zlog.Debugf("analytics: GoogleAnalytics enabled")
+		log.Debugf("analytics: GoogleAnalytics enabled")
 	}
 	if as.Plausible.Enabled {
-		zlog.Debugf("analytics: Plausible enabled")
+		log.Debugf("analytics: Plausible enabled")
 	}
 
 	ct := ext.Comments
 	if ct.Enabled {
-		zlog.Debugf("comments: enabled")
+		log.Debugf("comments: enabled")
 		if ct.Disqus.Enabled {
-			zlog.Debugf("comments: Disqus enabled")
+			log.Debugf("comments: Disqus enabled")
 		}
 	}
 }
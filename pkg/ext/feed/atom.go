@@ -9,6 +9,8 @@ package feed
 
 import (
 	"encoding/xml"
+	"net/url"
+	"path/filepath"
 	"pugo/pkg/core/models"
 	"pugo/pkg/utils"
 	"time"
@@ -60,26 +62,72 @@ func AtomTime(t time.Time) AtomTimeStr {
 	return AtomTimeStr(t.Format("2006-01-02T15:04:05-07:00"))
 }
 
+// TagURI returns a tag URI (RFC 4151) of base url host, date and specific part.
+// The date is formatted in its own location, not converted to UTC.
+func TagURI(baseURL string, date time.Time, specific string) string {
+	host := "localhost"
+	if u, err := url.Parse(baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return "tag:" + host + "," + date.Format("2006-01-02") + ":" + specific
+}
+
+// entryID returns the id of post in all feed formats, it does not change with link format or timezone.
+// The date is the day written in front-matter, so it keeps the same day in any site timezone.
+func entryID(baseURL string, p *models.Post) string {
+	specific := p.ID
+	if specific == "" {
+		specific = filepath.ToSlash(p.LocalFile())
+	}
+	return TagURI(baseURL, p.WrittenDate(), specific)
+}
+
+func newAtomPerson(a *models.Author) *AtomPerson {
+	return &AtomPerson{
+		Name:  a.Name,
+		URI:   a.Website,
+		Email: a.Email,
+	}
+}
+
 // BuildAtom builds an Atom feed from the given source.
-func BuildAtom(link string, posts []*models.Post, baseURL, title string) *AtomFeed {
+func BuildAtom(link string, posts []*models.Post, params *RenderParams) *AtomFeed {
 	feed := AtomFeed{
-		Title: title,
-		// ID:      "tag:" + s.cfg.Hostname + ",2013:" + s.cfg.Hostname,
-		Updated: AtomTime(posts[0].Date()),
+		Title: params.SiteTitle,
+		ID:    TagURI(params.SiteBaseURL, params.Config.GetTagURIDate(params.Logger), link),
 		Link: []AtomLink{{
 			Rel:  "self",
-			Href: utils.FullURL(baseURL, link),
+			Href: utils.FullURL(params.SiteBaseURL, link),
+			Type: formatTypes[FormatAtom],
+		}, {
+			Rel:  "alternate",
+			Href: utils.FullURL(params.SiteBaseURL, params.HomeLink),
+			Type: "text/html",
 		}},
 	}
+	if params.Author != nil && params.Author.Valid() {
+		feed.Author = newAtomPerson(params.Author)
+	}
 	for _, p := range posts {
-		feed.Entry = append(feed.Entry, &AtomEntry{
-			Title: p.Title,
-			Link: []AtomLink{{
-				Rel:  "alternate",
-				Href: utils.FullURL(baseURL, p.Link),
-			}},
+		links := []AtomLink{{
+			Rel:      "alternate",
+			Href:     utils.FullURL(params.SiteBaseURL, p.Link),
+			HrefLang: p.Lang,
+		}}
+		// same post in other languages
+		for _, t := range p.Translations {
+			links = append(links, AtomLink{
+				Rel:      "alternate",
+				Href:     utils.FullURL(params.SiteBaseURL, t.Link),
+				HrefLang: t.Lang,
+			})
+		}
+		entry := &AtomEntry{
+			Title:     p.Title,
+			ID:        entryID(params.SiteBaseURL, p),
+			Link:      links,
 			Published: AtomTime(p.Date()),
-			Updated:   AtomTime(p.Date()),
+			Updated:   AtomTime(p.Updated()),
 			Summary: &AtomText{
 				Type: "html",
 				Body: p.Brief(),
@@ -88,10 +136,12 @@ func BuildAtom(link string, posts []*models.Post, baseURL, title string) *AtomFe
 				Type: "html",
 				Body: p.Content(),
 			},
-			Author: &AtomPerson{
-				Name: p.Author.Name,
-			},
-		})
+		}
+		if p.Author != nil {
+			entry.Author = newAtomPerson(p.Author)
+		}
+		feed.Entry = append(feed.Entry, entry)
 	}
+	feed.Updated = AtomTime(latestUpdated(posts))
 	return &feed
 }
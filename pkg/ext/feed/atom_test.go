@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"bytes"
+	"pugo/pkg/core/models"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAtomEntryIDStable(t *testing.T) {
+	fsys := fstest.MapFS{
+		"content/posts/hello.md": {Data: []byte("---\ntitle: Hello\ndate: 2022-02-01 01:00:00 +08:00\n---\nhello\n")},
+		"content/posts/moved.md": {Data: []byte("---\ntitle: Moved\nid: first-post\ndate: 2022-02-02 10:00:00\n---\nmoved\n")},
+	}
+	entryIDs := func(loc *time.Location, linkPrefix string) []string {
+		var posts []*models.Post
+		for _, file := range []string{"content/posts/hello.md", "content/posts/moved.md"} {
+			p, err := models.NewPostFromFile(fsys, file, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Link = linkPrefix + p.Slug + "/"
+			posts = append(posts, p)
+		}
+		params := &RenderParams{Config: DefaultConfig(), SiteBaseURL: "https://example.com"}
+		atom := BuildAtom("/atom.xml", posts, params)
+		rss := BuildRSS("/rss.xml", posts, params)
+		json := BuildJSON("/feed.json", posts, params)
+		var ids []string
+		for i, e := range atom.Entry {
+			guid := rss.Channel.Items[i].GUID
+			if guid.IsPermaLink || guid.Value != e.ID || json.Items[i].ID != e.ID {
+				t.Fatalf("feeds should use the same entry id: %s, %+v, %s", e.ID, guid, json.Items[i].ID)
+			}
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	ids := entryIDs(time.UTC, "/2022/02/")
+	expected := []string{
+		"tag:example.com,2022-02-01:content/posts/hello.md",
+		"tag:example.com,2022-02-02:first-post",
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Fatalf("unexpected entry id: %s, expected: %s", ids[i], expected[i])
+		}
+	}
+	moved := entryIDs(time.FixedZone("UTC+11", 11*3600), "/posts/")
+	for i := range ids {
+		if moved[i] != ids[i] {
+			t.Fatalf("entry id changed with link format and timezone: %s, %s", moved[i], ids[i])
+		}
+	}
+}
+
+func TestAtomXMLHeader(t *testing.T) {
+	data, err := marshalFeed(&Link{Format: FormatAtom, Link: "/atom.xml"}, nil, &RenderParams{Config: DefaultConfig()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("<?xml")) {
+		t.Fatalf("atom feed should start with xml header: %s", data)
+	}
+}
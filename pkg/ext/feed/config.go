@@ -1,13 +1,72 @@
 package feed
 
+import (
+	"bytes"
+	"path"
+	"pugo/pkg/utils/zlog"
+	"strings"
+	"text/template"
+	"time"
+)
+
 const (
-	DefaultLimitNums = 10
+	DefaultLimitNums     = 10
+	DefaultSubLinkFormat = "{{.Link}}{{.File}}"
+)
+
+// feed formats
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+	FormatJSON = "json"
+)
+
+var (
+	formatTypes = map[string]string{
+		FormatAtom: "application/atom+xml",
+		FormatRSS:  "application/rss+xml",
+		FormatJSON: "application/feed+json",
+	}
+	formatLinks = map[string]string{
+		FormatRSS:  "/rss.xml",
+		FormatJSON: "/feed.json",
+	}
 )
 
 type Config struct {
 	Enabled   bool   `toml:"enabled"`
 	LimitNums int    `toml:"limit_nums"`
+	Link      string `toml:"link"` // atom feed link, used if atom.link is empty
+
+	Atom *FormatConfig `toml:"atom"`
+	RSS  *FormatConfig `toml:"rss"`
+	JSON *FormatConfig `toml:"json"`
+
+	// date in tag URI id of feeds, such as "2022-01-01", "2000-01-01" if empty.
+	// It should not be changed after feeds are published.
+	TagURIDate string `toml:"tag_uri_date"`
+
+	// feeds of each tag and author in all enabled formats,
+	// link format data is {{.Link}} of tag or author page, {{.File}} of site feed, such as "atom.xml"
+	TagFeeds         bool   `toml:"tag_feeds"`
+	TagLinkFormat    string `toml:"tag_link_format"`
+	AuthorFeeds      bool   `toml:"author_feeds"`
+	AuthorLinkFormat string `toml:"author_link_format"`
+}
+
+// FormatConfig is the config of one feed format.
+type FormatConfig struct {
+	Enabled   bool   `toml:"enabled"`
 	Link      string `toml:"link"`
+	LimitNums int    `toml:"limit_nums"` // use limit_nums of feed if zero
+}
+
+// Link is an enabled feed with resolved link and limit.
+type Link struct {
+	Format    string
+	Type      string // mime type, used in <link rel="alternate">
+	Link      string
+	LimitNums int
 }
 
 func DefaultConfig() *Config {
@@ -15,5 +74,164 @@ func DefaultConfig() *Config {
 		Enabled:   true,
 		LimitNums: DefaultLimitNums,
 		Link:      "/atom.xml",
+		Atom:      &FormatConfig{Enabled: true},
+		RSS:       &FormatConfig{Enabled: false, Link: formatLinks[FormatRSS]},
+		JSON:      &FormatConfig{Enabled: false, Link: formatLinks[FormatJSON]},
+
+		TagLinkFormat:    DefaultSubLinkFormat,
+		AuthorLinkFormat: DefaultSubLinkFormat,
+	}
+}
+
+// GetLimitNums returns the max number of posts in feed.
+func (c *Config) GetLimitNums() int {
+	if c.LimitNums <= 0 {
+		return DefaultLimitNums
+	}
+	return c.LimitNums
+}
+
+// GetTagURIDate returns the date in feed ids, it is fixed unless tag_uri_date is set,
+// so feed ids do not change when posts are added or removed.
+// Invalid tag_uri_date is logged to log, or the global logger if log is nil.
+func (c *Config) GetTagURIDate(log zlog.Logger) time.Time {
+	if c.TagURIDate != "" {
+		if t, err := time.Parse("2006-01-02", c.TagURIDate); err == nil {
+			return t
+		}
+		zlog.OrStd(log).Warnf("feed: invalid tag_uri_date: %s", c.TagURIDate)
+	}
+	return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Links returns all enabled feeds, atom is the first if enabled.
+func (c *Config) Links() []*Link {
+	if c == nil || !c.Enabled {
+		return nil
+	}
+	var links []*Link
+	add := func(format string, fc *FormatConfig) {
+		if fc == nil || !fc.Enabled {
+			return
+		}
+		link := &Link{
+			Format:    format,
+			Type:      formatTypes[format],
+			Link:      fc.Link,
+			LimitNums: fc.LimitNums,
+		}
+		if link.Link == "" {
+			link.Link = c.defaultLink(format)
+		}
+		if link.LimitNums <= 0 {
+			link.LimitNums = c.GetLimitNums()
+		}
+		links = append(links, link)
+	}
+	// config without atom section is an atom feed
+	atom := c.Atom
+	if atom == nil {
+		atom = &FormatConfig{Enabled: true}
+	}
+	add(FormatAtom, atom)
+	add(FormatRSS, c.RSS)
+	add(FormatJSON, c.JSON)
+	return links
+}
+
+func (c *Config) defaultLink(format string) string {
+	if format == FormatAtom {
+		return c.Link
+	}
+	return formatLinks[format]
+}
+
+// MaxLimitNums returns the max number of posts in feeds.
+func MaxLimitNums(links []*Link) int {
+	max := 0
+	for _, l := range links {
+		if l.LimitNums > max {
+			max = l.LimitNums
+		}
+	}
+	return max
+}
+
+// WithLinkPrefix returns a copy of config with prefix in all links.
+func (c *Config) WithLinkPrefix(prefix string) *Config {
+	cfg := *c
+	cfg.Link = prefix + c.Link
+	prefixFormat := func(format string, fc *FormatConfig) *FormatConfig {
+		if fc == nil {
+			return nil
+		}
+		fc2 := *fc
+		if fc2.Link != "" {
+			fc2.Link = prefix + fc2.Link
+		} else if format != FormatAtom {
+			fc2.Link = prefix + formatLinks[format]
+		}
+		return &fc2
+	}
+	cfg.Atom = prefixFormat(FormatAtom, c.Atom)
+	cfg.RSS = prefixFormat(FormatRSS, c.RSS)
+	cfg.JSON = prefixFormat(FormatJSON, c.JSON)
+	return &cfg
+}
+
+// Primary returns the first enabled feed, nil if no feed is enabled.
+func (c *Config) Primary() *Link {
+	if links := c.Links(); len(links) > 0 {
+		return links[0]
+	}
+	return nil
+}
+
+// TagLinks returns feeds of tag page with link, nil if tag feeds are disabled.
+// Invalid link format is logged to log, or the global logger if log is nil.
+func (c *Config) TagLinks(link string, log zlog.Logger) []*Link {
+	if c == nil || !c.TagFeeds {
+		return nil
+	}
+	return c.subLinks(c.TagLinkFormat, link, log)
+}
+
+// AuthorLinks returns feeds of author page with link, nil if author feeds are disabled.
+// Invalid link format is logged to log, or the global logger if log is nil.
+func (c *Config) AuthorLinks(link string, log zlog.Logger) []*Link {
+	if c == nil || !c.AuthorFeeds {
+		return nil
+	}
+	return c.subLinks(c.AuthorLinkFormat, link, log)
+}
+
+func (c *Config) subLinks(format, pageLink string, log zlog.Logger) []*Link {
+	if format == "" {
+		format = DefaultSubLinkFormat
+	}
+	tpl, err := template.New("feed-link").Parse(format)
+	if err != nil {
+		zlog.OrStd(log).Warnf("feed: failed to parse link format: %s, %s", format, err)
+		return nil
+	}
+	if !strings.HasSuffix(pageLink, "/") {
+		pageLink += "/"
+	}
+	var links []*Link
+	for _, l := range c.Links() {
+		var buf bytes.Buffer
+		data := map[string]interface{}{
+			"Link":   pageLink,
+			"File":   path.Base(l.Link),
+			"Format": l.Format,
+		}
+		if err := tpl.Execute(&buf, data); err != nil {
+			zlog.OrStd(log).Warnf("feed: failed to build link: %s, %s", pageLink, err)
+			return nil
+		}
+		sub := *l
+		sub.Link = buf.String()
+		links = append(links, &sub)
 	}
+	return links
 }
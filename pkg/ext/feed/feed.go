@@ -2,45 +2,103 @@ package feed
 
 import (
 	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"net/url"
 	"path/filepath"
 	"pugo/pkg/core/models"
 	"pugo/pkg/utils/zlog"
+	"time"
 )
 
 // RenderParams represents the parameters for rendering the feed.
 type RenderParams struct {
-	Config      *Config
-	Posts       []*models.Post
-	SiteBaseURL string
-	SiteTitle   string
-	OutputDir   string
+	Config          *Config
+	Links           []*Link // feeds to render, all enabled feeds of config if empty
+	Posts           []*models.Post
+	SiteBaseURL     string
+	SiteTitle       string
+	SiteDescription string
+	HomeLink        string         // link of site home page, "/" if empty
+	Language        string         // language code of site, optional
+	Author          *models.Author // site author, optional
+	Generator       string
+	Validate        bool // if true, check generated feeds and fail if invalid
+	OutputDir       string
+	Logger          zlog.Logger // use the global logger if nil
 }
 
-// Render renders the feed.
-func Render(params *RenderParams) (*models.OutputFile, error) {
-	if params == nil || !params.Config.Enabled {
-		zlog.Debugf("feed is disabled")
+// Render renders all enabled feeds.
+func Render(params *RenderParams) ([]*models.OutputFile, error) {
+	if params == nil || params.Config == nil || !params.Config.Enabled || len(params.Posts) == 0 {
 		return nil, nil
 	}
-	var posts []*models.Post
-	var limit = params.Config.LimitNums
-	if limit <= 0 {
-		limit = DefaultLimitNums
+	if params.HomeLink == "" {
+		params.HomeLink = "/"
 	}
-	if limit > len(params.Posts) {
-		posts = params.Posts
-	} else {
-		posts = params.Posts[:limit]
+	links := params.Links
+	if len(links) == 0 {
+		links = params.Config.Links()
 	}
-	feed := BuildAtom(params.Config.Link, posts, params.SiteBaseURL, params.SiteTitle)
-	data, err := xml.Marshal(feed)
+	var outputs []*models.OutputFile
+	for _, link := range links {
+		posts := params.Posts
+		if len(posts) > link.LimitNums {
+			posts = posts[:link.LimitNums]
+		}
+		data, err := marshalFeed(link, posts, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s feed %s: %w", link.Format, link.Link, err)
+		}
+		if params.Validate {
+			if err := Validate(link.Format, data); err != nil {
+				return nil, fmt.Errorf("invalid %s feed %s: %w", link.Format, link.Link, err)
+			}
+		}
+		outputs = append(outputs, &models.OutputFile{
+			Path: filepath.Join(params.OutputDir, localFile(link.Link)),
+			Link: link.Link,
+			Buf:  bytes.NewBuffer(data),
+		})
+	}
+	return outputs, nil
+}
+
+func marshalFeed(link *Link, posts []*models.Post, params *RenderParams) ([]byte, error) {
+	switch link.Format {
+	case FormatAtom:
+		return marshalXML(BuildAtom(link.Link, posts, params))
+	case FormatRSS:
+		return marshalXML(BuildRSS(link.Link, posts, params))
+	case FormatJSON:
+		return json.Marshal(BuildJSON(link.Link, posts, params))
+	}
+	return nil, fmt.Errorf("unknown feed format: %s", link.Format)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
 	if err != nil {
-		zlog.Warnf("failed to marshal atom feed: %s", err)
 		return nil, err
 	}
-	return &models.OutputFile{
-		Path: filepath.Join(params.OutputDir, params.Config.Link),
-		Buf:  bytes.NewBuffer(data),
-	}, nil
+	return append([]byte(xml.Header), data...), nil
+}
+
+// latestUpdated returns the latest updated date of posts.
+func latestUpdated(posts []*models.Post) (t time.Time) {
+	for _, p := range posts {
+		if p.Updated().After(t) {
+			t = p.Updated()
+		}
+	}
+	return t
+}
+
+// localFile returns the file path of link, author links may be escaped.
+func localFile(link string) string {
+	if file, err := url.PathUnescape(link); err == nil {
+		return file
+	}
+	return link
 }
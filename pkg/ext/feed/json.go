@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type (
+	JSONFeed struct {
+		Version     string            `json:"version"`
+		Title       string            `json:"title"`
+		HomePageURL string            `json:"home_page_url"`
+		FeedURL     string            `json:"feed_url"`
+		Description string            `json:"description,omitempty"`
+		Language    string            `json:"language,omitempty"`
+		Authors     []*JSONFeedAuthor `json:"authors,omitempty"`
+		Items       []*JSONFeedItem   `json:"items"`
+	}
+	JSONFeedAuthor struct {
+		Name   string `json:"name"`
+		URL    string `json:"url,omitempty"`
+		Avatar string `json:"avatar,omitempty"`
+	}
+	JSONFeedItem struct {
+		ID            string            `json:"id"`
+		URL           string            `json:"url"`
+		Title         string            `json:"title"`
+		ContentHTML   string            `json:"content_html"`
+		Summary       string            `json:"summary,omitempty"`
+		DatePublished string            `json:"date_published"`
+		DateModified  string            `json:"date_modified,omitempty"`
+		Authors       []*JSONFeedAuthor `json:"authors,omitempty"`
+		Tags          []string          `json:"tags,omitempty"`
+		Language      string            `json:"language,omitempty"`
+	}
+)
+
+func newJSONFeedAuthor(a *models.Author) *JSONFeedAuthor {
+	return &JSONFeedAuthor{
+		Name:   a.Name,
+		URL:    a.Website,
+		Avatar: a.AvatarLink(),
+	}
+}
+
+// BuildJSON builds a JSON Feed 1.1 from the given source.
+func BuildJSON(link string, posts []*models.Post, params *RenderParams) *JSONFeed {
+	feed := &JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       params.SiteTitle,
+		HomePageURL: utils.FullURL(params.SiteBaseURL, params.HomeLink),
+		FeedURL:     utils.FullURL(params.SiteBaseURL, link),
+		Description: params.SiteDescription,
+		Language:    params.Language,
+		Items:       make([]*JSONFeedItem, 0, len(posts)),
+	}
+	if params.Author != nil && params.Author.Valid() {
+		feed.Authors = []*JSONFeedAuthor{newJSONFeedAuthor(params.Author)}
+	}
+	for _, p := range posts {
+		fullLink := utils.FullURL(params.SiteBaseURL, p.Link)
+		item := &JSONFeedItem{
+			ID:            entryID(params.SiteBaseURL, p),
+			URL:           fullLink,
+			Title:         p.Title,
+			ContentHTML:   p.Content(),
+			Summary:       p.Descripition,
+			DatePublished: p.Date().Format(time.RFC3339),
+			DateModified:  p.Updated().Format(time.RFC3339),
+			Tags:          p.Tags,
+			Language:      p.Lang,
+		}
+		for _, a := range p.Authors() {
+			item.Authors = append(item.Authors, newJSONFeedAuthor(a))
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed
+}
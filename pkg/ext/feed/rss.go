@@ -0,0 +1,98 @@
+package feed
+
+import (
+	"encoding/xml"
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"time"
+)
+
+type (
+	RSSFeed struct {
+		XMLName      xml.Name    `xml:"rss"`
+		Version      string      `xml:"version,attr"`
+		XmlnsAtom    string      `xml:"xmlns:atom,attr"`
+		XmlnsContent string      `xml:"xmlns:content,attr"`
+		XmlnsDC      string      `xml:"xmlns:dc,attr"`
+		Channel      *RSSChannel `xml:"channel"`
+	}
+	RSSChannel struct {
+		Title         string     `xml:"title"`
+		Link          string     `xml:"link"`
+		Description   string     `xml:"description"`
+		Language      string     `xml:"language,omitempty"`
+		LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+		Generator     string     `xml:"generator,omitempty"`
+		AtomLink      *AtomLink  `xml:"atom:link"`
+		Items         []*RSSItem `xml:"item"`
+	}
+	RSSItem struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		GUID        *RSSGUID  `xml:"guid"`
+		PubDate     string    `xml:"pubDate"`
+		Author      string    `xml:"author,omitempty"`
+		Creator     []string  `xml:"dc:creator"`
+		Categories  []string  `xml:"category"`
+		Description *RSSCDATA `xml:"description"`
+		Content     *RSSCDATA `xml:"content:encoded"`
+	}
+	RSSGUID struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	RSSCDATA struct {
+		Body string `xml:",cdata"`
+	}
+)
+
+// RSSTime formats time in RFC 822 as RSS 2.0 requires.
+func RSSTime(t time.Time) string {
+	return t.Format(time.RFC1123Z)
+}
+
+// BuildRSS builds a RSS 2.0 feed from the given source.
+func BuildRSS(link string, posts []*models.Post, params *RenderParams) *RSSFeed {
+	channel := &RSSChannel{
+		Title:       params.SiteTitle,
+		Link:        utils.FullURL(params.SiteBaseURL, params.HomeLink),
+		Description: params.SiteDescription,
+		Language:    params.Language,
+		Generator:   params.Generator,
+		AtomLink: &AtomLink{
+			Rel:  "self",
+			Href: utils.FullURL(params.SiteBaseURL, link),
+			Type: formatTypes[FormatRSS],
+		},
+	}
+	if len(posts) > 0 {
+		channel.LastBuildDate = RSSTime(latestUpdated(posts))
+	}
+	for _, p := range posts {
+		fullLink := utils.FullURL(params.SiteBaseURL, p.Link)
+		item := &RSSItem{
+			Title:       p.Title,
+			Link:        fullLink,
+			GUID:        &RSSGUID{IsPermaLink: false, Value: entryID(params.SiteBaseURL, p)},
+			PubDate:     RSSTime(p.Date()),
+			Categories:  p.Tags,
+			Description: &RSSCDATA{Body: p.Brief()},
+			Content:     &RSSCDATA{Body: p.Content()},
+		}
+		// author element must be an email address, names are in dc:creator
+		for _, a := range p.Authors() {
+			if item.Author == "" && a.Email != "" {
+				item.Author = a.Email + " (" + a.Name + ")"
+			}
+			item.Creator = append(item.Creator, a.Name)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	return &RSSFeed{
+		Version:      "2.0",
+		XmlnsAtom:    "http://www.w3.org/2005/Atom",
+		XmlnsContent: "http://purl.org/rss/1.0/modules/content/",
+		XmlnsDC:      "http://purl.org/dc/elements/1.1/",
+		Channel:      channel,
+	}
+}
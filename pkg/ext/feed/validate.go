@@ -0,0 +1,186 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validate checks the generated feed data of format,
+// it returns an error with all problems found, or nil if the feed is valid.
+func Validate(format string, data []byte) error {
+	var problems []string
+	switch format {
+	case FormatAtom:
+		problems = validateAtom(data)
+	case FormatRSS:
+		problems = validateRSS(data)
+	case FormatJSON:
+		problems = validateJSON(data)
+	default:
+		return fmt.Errorf("unknown feed format: %s", format)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// problemList collects problems of a feed.
+type problemList []string
+
+func (l *problemList) addf(format string, args ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, args...))
+}
+
+func (l *problemList) checkTime(name, value, layout string) {
+	if value == "" {
+		l.addf("%s is missing", name)
+		return
+	}
+	if _, err := time.Parse(layout, value); err != nil {
+		l.addf("%s is invalid: %s", name, value)
+	}
+}
+
+func validateAtom(data []byte) []string {
+	var (
+		feed     AtomFeed
+		problems problemList
+	)
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return []string{"invalid xml: " + err.Error()}
+	}
+	if feed.ID == "" {
+		problems.addf("feed id is missing")
+	}
+	if feed.Title == "" {
+		problems.addf("feed title is missing")
+	}
+	problems.checkTime("feed updated", string(feed.Updated), time.RFC3339)
+	hasSelf := false
+	for _, l := range feed.Link {
+		hasSelf = hasSelf || l.Rel == "self"
+	}
+	if !hasSelf {
+		problems.addf("feed self link is missing")
+	}
+	ids := make(map[string]bool)
+	for i, e := range feed.Entry {
+		name := fmt.Sprintf("entry %d", i+1)
+		if e.ID == "" {
+			problems.addf("%s id is missing", name)
+		} else if ids[e.ID] {
+			problems.addf("%s id is duplicated: %s", name, e.ID)
+		}
+		ids[e.ID] = true
+		if e.Title == "" {
+			problems.addf("%s title is missing", name)
+		}
+		problems.checkTime(name+" updated", string(e.Updated), time.RFC3339)
+		// entry without author inherits feed author
+		if feed.Author == nil && (e.Author == nil || e.Author.Name == "") {
+			problems.addf("%s author is missing", name)
+		}
+		if len(e.Link) == 0 && e.Content == nil {
+			problems.addf("%s has no alternate link or content", name)
+		}
+	}
+	return problems
+}
+
+type rssCheck struct {
+	Channel struct {
+		Title       string         `xml:"title"`
+		Links       []rssCheckLink `xml:"link"`
+		Description string         `xml:"description"`
+		Items       []struct {
+			Title       string `xml:"title"`
+			Description string `xml:"description"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// rssCheckLink is the link of channel, atom:link is in the same list.
+type rssCheckLink struct {
+	XMLName xml.Name `xml:"link"`
+	Value   string   `xml:",chardata"`
+}
+
+func validateRSS(data []byte) []string {
+	var (
+		feed     rssCheck
+		problems problemList
+	)
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return []string{"invalid xml: " + err.Error()}
+	}
+	if feed.Channel.Title == "" {
+		problems.addf("channel title is missing")
+	}
+	hasLink := false
+	for _, l := range feed.Channel.Links {
+		hasLink = hasLink || (l.XMLName.Space == "" && l.Value != "")
+	}
+	if !hasLink {
+		problems.addf("channel link is missing")
+	}
+	if feed.Channel.Description == "" {
+		problems.addf("channel description is missing")
+	}
+	guids := make(map[string]bool)
+	for i, item := range feed.Channel.Items {
+		name := fmt.Sprintf("item %d", i+1)
+		if item.Title == "" && item.Description == "" {
+			problems.addf("%s has no title or description", name)
+		}
+		if item.GUID != "" {
+			if guids[item.GUID] {
+				problems.addf("%s guid is duplicated: %s", name, item.GUID)
+			}
+			guids[item.GUID] = true
+		}
+		if item.PubDate != "" {
+			problems.checkTime(name+" pubDate", item.PubDate, time.RFC1123Z)
+		}
+	}
+	return problems
+}
+
+func validateJSON(data []byte) []string {
+	var (
+		feed     JSONFeed
+		problems problemList
+	)
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return []string{"invalid json: " + err.Error()}
+	}
+	if feed.Version != jsonFeedVersion {
+		problems.addf("version is invalid: %s", feed.Version)
+	}
+	if feed.Title == "" {
+		problems.addf("title is missing")
+	}
+	ids := make(map[string]bool)
+	for i, item := range feed.Items {
+		name := fmt.Sprintf("item %d", i+1)
+		if item.ID == "" {
+			problems.addf("%s id is missing", name)
+		} else if ids[item.ID] {
+			problems.addf("%s id is duplicated: %s", name, item.ID)
+		}
+		ids[item.ID] = true
+		if item.ContentHTML == "" {
+			problems.addf("%s content is missing", name)
+		}
+		if item.DatePublished != "" {
+			problems.checkTime(name+" date_published", item.DatePublished, time.RFC3339)
+		}
+	}
+	return problems
+}
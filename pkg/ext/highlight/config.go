@@ -0,0 +1,20 @@
+package highlight
+
+// Config is the config of highlighting code blocks in building.
+type Config struct {
+	Enabled     bool   `toml:"enabled"`      // highlight code blocks in building, themes need not highlight them by scripts
+	Style       string `toml:"style"`        // style of generated css, such as "github", list styles by "pugo highlight --list"
+	DarkStyle   string `toml:"dark_style"`   // style in dark mode of theme, empty to use Style
+	LineNumbers bool   `toml:"line_numbers"` // show line numbers in code blocks
+	CSSFile     string `toml:"css_file"`     // link of generated css, it is not generated if a static file is at the link
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:     false,
+		Style:       "github",
+		DarkStyle:   "monokai",
+		LineNumbers: false,
+		CSSFile:     "/static/css/highlight.css",
+	}
+}
@@ -0,0 +1,109 @@
+// Package highlight highlights code blocks in building, code is styled by css classes of chroma.
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// DarkClass is the class of html element in dark mode of theme.
+const DarkClass = "dark"
+
+var reLineRange = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+
+// ParseInfo parses info of fenced code block, such as "go {3-5,8}",
+// it returns the language and ranges of highlighted lines.
+func ParseInfo(info string) (string, [][2]int) {
+	info = strings.TrimSpace(info)
+	lang := info
+	var lines [][2]int
+	if i := strings.IndexAny(info, " {"); i >= 0 {
+		lang = info[:i]
+		attrs := strings.TrimSpace(info[i:])
+		if strings.HasPrefix(attrs, "{") && strings.HasSuffix(attrs, "}") {
+			for _, r := range strings.FieldsFunc(attrs[1:len(attrs)-1], func(c rune) bool { return c == ',' || c == ' ' }) {
+				m := reLineRange.FindStringSubmatch(r)
+				if m == nil {
+					continue
+				}
+				start, _ := strconv.Atoi(m[1])
+				end := start
+				if m[2] != "" {
+					end, _ = strconv.Atoi(m[2])
+				}
+				if start <= end {
+					lines = append(lines, [2]int{start, end})
+				}
+			}
+		}
+	}
+	return lang, lines
+}
+
+// Write writes highlighted html of code in lang, lines are ranges of highlighted lines.
+// Code in unknown language is written as plain text.
+func Write(w io.Writer, code, lang string, lines [][2]int, cfg *Config) error {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return err
+	}
+	formatter := html.New(
+		html.WithClasses(true),
+		html.WithLineNumbers(cfg.LineNumbers),
+		html.HighlightLines(lines),
+	)
+	return formatter.Format(w, styles.Fallback, iterator)
+}
+
+// WriteCSS writes css of style for highlighted code,
+// rules of darkStyle are scoped in DarkClass if it is not empty.
+func WriteCSS(w io.Writer, style, darkStyle string) error {
+	if !HasStyle(style) {
+		return fmt.Errorf("unknown style: %s", style)
+	}
+	formatter := html.New(html.WithClasses(true), html.WithLineNumbers(true))
+	if err := formatter.WriteCSS(w, styles.Get(style)); err != nil {
+		return err
+	}
+	if darkStyle == "" || darkStyle == style {
+		return nil
+	}
+	if !HasStyle(darkStyle) {
+		return fmt.Errorf("unknown style: %s", darkStyle)
+	}
+	var buf bytes.Buffer
+	if err := formatter.WriteCSS(&buf, styles.Get(darkStyle)); err != nil {
+		return err
+	}
+	// each rule is in one line as "/* comment */ .selector { ... }"
+	dark := strings.ReplaceAll(buf.String(), "*/ .", "*/ ."+DarkClass+" .")
+	_, err := io.WriteString(w, dark)
+	return err
+}
+
+// HasStyle returns whether the style is known.
+func HasStyle(name string) bool {
+	_, ok := styles.Registry[name]
+	return ok
+}
+
+// Styles returns names of all styles.
+func Styles() []string {
+	names := styles.Names()
+	sort.Strings(names)
+	return names
+}
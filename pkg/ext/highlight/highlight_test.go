@@ -0,0 +1,27 @@
+package highlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		info  string
+		lang  string
+		lines [][2]int
+	}{
+		{"", "", nil},
+		{"go", "go", nil},
+		{"go {3-5}", "go", [][2]int{{3, 5}}},
+		{"go{1,3-4, 8}", "go", [][2]int{{1, 1}, {3, 4}, {8, 8}}},
+		{"js {5-3,x}", "js", nil},
+		{"python title", "python", nil},
+	}
+	for _, tt := range tests {
+		lang, lines := ParseInfo(tt.info)
+		if lang != tt.lang || !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("ParseInfo(%q) = %q, %v, want %q, %v", tt.info, lang, lines, tt.lang, tt.lines)
+		}
+	}
+}
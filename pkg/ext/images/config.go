@@ -0,0 +1,22 @@
+package images
+
+// Config is the config of processing images in contents.
+type Config struct {
+	Enabled bool   `toml:"enabled"` // resize images referenced in markdown and render them as responsive pictures
+	Widths  []int  `toml:"widths"`  // widths of resized images, only widths smaller than original image are generated
+	WebP    bool   `toml:"webp"`    // convert png images to lossless webp, each size is kept only if smaller than png
+	Quality int    `toml:"quality"` // quality of resized jpeg images, 1 to 100, jpeg images are not converted to webp
+	Lazy    bool   `toml:"lazy"`    // add loading="lazy" to images
+	Sizes   string `toml:"sizes"`   // sizes attribute of srcset
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled: false,
+		Widths:  []int{480, 960, 1440},
+		WebP:    true,
+		Quality: 85,
+		Lazy:    true,
+		Sizes:   "100vw",
+	}
+}
@@ -0,0 +1,329 @@
+// Package images resizes images referenced in contents and converts png images to webp.
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"pugo/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+)
+
+// cacheVersion is changed when processed files are different for the same config.
+const cacheVersion = "2"
+
+// Variant is a resized or converted file of an image.
+type Variant struct {
+	Link  string `json:"link"`
+	Width int    `json:"width"`
+	WebP  bool   `json:"webp"`
+	Data  []byte `json:"-"`
+}
+
+// Image is a processed image, Variants are in order of width and do not include the original image.
+type Image struct {
+	Link     string     `json:"link"`
+	Width    int        `json:"width"`
+	Height   int        `json:"height"`
+	Variants []*Variant `json:"variants"`
+}
+
+// Srcset returns srcset of variants in original format, original image is included.
+// If webp is true, the webp variant of each width is used instead if it exists,
+// and it returns empty if no variant is converted to webp.
+func (img *Image) Srcset(webp bool) string {
+	webps := make(map[int]string)
+	for _, v := range img.Variants {
+		if v.WebP {
+			webps[v.Width] = v.Link
+		}
+	}
+	if webp && len(webps) == 0 {
+		return ""
+	}
+	var candidates []string
+	add := func(link string, width int) {
+		if l, ok := webps[width]; webp && ok {
+			link = l
+		}
+		candidates = append(candidates, link+" "+strconv.Itoa(width)+"w")
+	}
+	for _, v := range img.Variants {
+		if !v.WebP {
+			add(v.Link, v.Width)
+		}
+	}
+	add(img.Link, img.Width)
+	return strings.Join(candidates, ", ")
+}
+
+// Processor processes images on first use, it is safe for concurrent use.
+type Processor struct {
+	cfg      *Config
+	fsys     fs.FS
+	files    map[string]string
+	cacheDir string
+	items    sync.Map
+}
+
+type processedItem struct {
+	once sync.Once
+	key  string
+	img  *Image
+	err  error
+}
+
+// NewProcessor returns a processor of images in files, which maps links to paths in fsys.
+// Processed images are cached in cacheDir, the cache is disabled if cacheDir is empty.
+func NewProcessor(cfg *Config, fsys fs.FS, files map[string]string, cacheDir string) *Processor {
+	return &Processor{
+		cfg:      cfg,
+		fsys:     fsys,
+		files:    files,
+		cacheDir: cacheDir,
+	}
+}
+
+// Get returns the processed image of link, or nil if link is not a local png or jpeg image.
+func (p *Processor) Get(link string) (*Image, error) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return nil, nil
+	}
+	src, ok := p.files[u.Path]
+	if !ok || formatOf(u.Path) == "" {
+		return nil, nil
+	}
+	v, _ := p.items.LoadOrStore(u.Path, &processedItem{})
+	item := v.(*processedItem)
+	item.once.Do(func() {
+		item.key, item.img, item.err = p.process(u.Path, src)
+	})
+	return item.img, item.err
+}
+
+// Processed returns true if link is a processed image.
+func (p *Processor) Processed(link string) bool {
+	v, ok := p.items.Load(link)
+	return ok && v.(*processedItem).img != nil
+}
+
+// Images returns all processed images in order of links.
+func (p *Processor) Images() []*Image {
+	var images []*Image
+	p.items.Range(func(key, value interface{}) bool {
+		if img := value.(*processedItem).img; img != nil {
+			images = append(images, img)
+		}
+		return true
+	})
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Link < images[j].Link
+	})
+	return images
+}
+
+// Errors returns errors of processing images in order of links.
+func (p *Processor) Errors() []error {
+	var (
+		links []string
+		errs  = make(map[string]error)
+	)
+	p.items.Range(func(key, value interface{}) bool {
+		if err := value.(*processedItem).err; err != nil {
+			links = append(links, key.(string))
+			errs[key.(string)] = err
+		}
+		return true
+	})
+	sort.Strings(links)
+	result := make([]error, len(links))
+	for i, link := range links {
+		result[i] = errs[link]
+	}
+	return result
+}
+
+// Prune removes cached images not used by the processor.
+func (p *Processor) Prune() error {
+	if p.cacheDir == "" {
+		return nil
+	}
+	used := make(map[string]bool)
+	p.items.Range(func(key, value interface{}) bool {
+		used[value.(*processedItem).key] = true
+		return true
+	})
+	entries, err := os.ReadDir(p.cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if !used[e.Name()] {
+			if err := os.RemoveAll(filepath.Join(p.cacheDir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// process resizes and converts image of link, it returns the cache key and the image.
+func (p *Processor) process(link, src string) (string, *Image, error) {
+	data, err := fs.ReadFile(p.fsys, src)
+	if err != nil {
+		return "", nil, err
+	}
+	key := utils.MD5Bytes([]byte(fmt.Sprintf("%s|%s|%v|%v|%d|%s", cacheVersion, link, p.cfg.Widths, p.cfg.WebP, p.cfg.Quality, utils.MD5Bytes(data))))
+	if img := p.loadCache(key); img != nil {
+		return key, img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return key, nil, fmt.Errorf("failed to decode image %s: %w", src, err)
+	}
+	bounds := decoded.Bounds()
+	img := &Image{Link: link, Width: bounds.Dx(), Height: bounds.Dy()}
+
+	var (
+		format = formatOf(link)
+		base   = strings.TrimSuffix(link, path.Ext(link))
+		webps  []*Variant
+		// lossless webp is almost always larger than lossy jpeg, only png images are converted
+		toWebP = p.cfg.WebP && format == "png"
+	)
+	// addWebP keeps webp of each size only if it is smaller than the file in original format
+	addWebP := func(img image.Image, link string, width, size int) error {
+		var buf bytes.Buffer
+		if err := EncodeWebP(&buf, img); err != nil {
+			return err
+		}
+		if buf.Len() < size {
+			webps = append(webps, &Variant{Link: link, Width: width, WebP: true, Data: buf.Bytes()})
+		}
+		return nil
+	}
+	for _, width := range p.widths() {
+		if width >= img.Width {
+			continue
+		}
+		height := (img.Height*width + img.Width/2) / img.Width
+		if height < 1 {
+			height = 1
+		}
+		resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), decoded, bounds, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if format == "png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: p.quality()})
+		}
+		if err != nil {
+			return key, nil, err
+		}
+		img.Variants = append(img.Variants, &Variant{
+			Link:  base + "-" + strconv.Itoa(width) + "w" + path.Ext(link),
+			Width: width,
+			Data:  buf.Bytes(),
+		})
+		if toWebP {
+			if err = addWebP(resized, base+"-"+strconv.Itoa(width)+"w.webp", width, buf.Len()); err != nil {
+				return key, nil, err
+			}
+		}
+	}
+	if toWebP {
+		if err = addWebP(decoded, base+".webp", img.Width, len(data)); err != nil {
+			return key, nil, err
+		}
+	}
+	img.Variants = append(img.Variants, webps...)
+
+	if err = p.saveCache(key, img); err != nil {
+		return key, nil, err
+	}
+	return key, img, nil
+}
+
+func (p *Processor) widths() []int {
+	widths := append([]int(nil), p.cfg.Widths...)
+	sort.Ints(widths)
+	return widths
+}
+
+func (p *Processor) quality() int {
+	if p.cfg.Quality < 1 || p.cfg.Quality > 100 {
+		return jpeg.DefaultQuality
+	}
+	return p.cfg.Quality
+}
+
+// loadCache returns the cached image of key, or nil if not cached.
+func (p *Processor) loadCache(key string) *Image {
+	if p.cacheDir == "" {
+		return nil
+	}
+	dir := filepath.Join(p.cacheDir, key)
+	data, err := os.ReadFile(filepath.Join(dir, "image.json"))
+	if err != nil {
+		return nil
+	}
+	img := &Image{}
+	if err = json.Unmarshal(data, img); err != nil {
+		return nil
+	}
+	for _, v := range img.Variants {
+		if v.Data, err = os.ReadFile(filepath.Join(dir, path.Base(v.Link))); err != nil {
+			return nil
+		}
+	}
+	return img
+}
+
+// saveCache saves variants of image, the metadata is saved at last to mark it complete.
+func (p *Processor) saveCache(key string, img *Image) error {
+	if p.cacheDir == "" {
+		return nil
+	}
+	dir := filepath.Join(p.cacheDir, key)
+	for _, v := range img.Variants {
+		if err := utils.WriteFile(filepath.Join(dir, path.Base(v.Link)), v.Data); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(img)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filepath.Join(dir, "image.json"), data)
+}
+
+// formatOf returns the format of image file, or empty if not supported.
+func formatOf(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png":
+		return "png"
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"testing/fstest"
+)
+
+func TestImageSrcset(t *testing.T) {
+	img := &Image{Link: "/a.png", Width: 1000, Variants: []*Variant{
+		{Link: "/a-480w.png", Width: 480},
+		{Link: "/a-960w.png", Width: 960},
+		{Link: "/a-480w.webp", Width: 480, WebP: true},
+		{Link: "/a.webp", Width: 1000, WebP: true},
+	}}
+	if s := img.Srcset(false); s != "/a-480w.png 480w, /a-960w.png 960w, /a.png 1000w" {
+		t.Fatalf("unexpected srcset: %s", s)
+	}
+	// webp is larger in 960w, the png is used in webp srcset
+	if s := img.Srcset(true); s != "/a-480w.webp 480w, /a-960w.png 960w, /a.webp 1000w" {
+		t.Fatalf("unexpected webp srcset: %s", s)
+	}
+	img.Variants = img.Variants[:2]
+	if s := img.Srcset(true); s != "" {
+		t.Fatalf("webp srcset should be empty if not converted: %s", s)
+	}
+}
+
+func TestProcessorWebP(t *testing.T) {
+	src := testImage(image.Pt(200, 100))
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegBuf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"a.png": {Data: pngBuf.Bytes()},
+		"b.jpg": {Data: jpegBuf.Bytes()},
+	}
+	p := NewProcessor(&Config{Widths: []int{100}, WebP: true}, fsys, map[string]string{"/a.png": "a.png", "/b.jpg": "b.jpg"}, "")
+
+	img, err := p.Get("/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make(map[int]int)
+	for _, v := range img.Variants {
+		if !v.WebP {
+			sizes[v.Width] = len(v.Data)
+		}
+	}
+	sizes[img.Width] = pngBuf.Len()
+	for _, v := range img.Variants {
+		if v.WebP && len(v.Data) >= sizes[v.Width] {
+			t.Fatalf("webp %s should be kept only if smaller: %d >= %d", v.Link, len(v.Data), sizes[v.Width])
+		}
+	}
+
+	img, err = p.Get("/b.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(img.Variants) != 1 || img.Variants[0].WebP || img.Srcset(true) != "" {
+		t.Fatalf("jpeg should only be resized: %+v", img.Variants)
+	}
+}
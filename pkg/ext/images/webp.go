@@ -0,0 +1,494 @@
+package images
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+)
+
+// EncodeWebP writes img as a lossless WebP (VP8L) image.
+// It uses subtract green and predictor transforms and backward references of runs,
+// which is simple but works well for screenshots and diagrams.
+// It is written here because golang.org/x/image/webp only decodes and libwebp needs cgo,
+// the binary should stay pure Go to cross compile.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > 1<<14 || height > 1<<14 {
+		return errors.New("webp: invalid image size")
+	}
+
+	pixels := make([]uint32, 0, width*height)
+	hasAlpha := false
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			pixels = append(pixels, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8) // signature
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // version
+
+	// subtract green transform
+	subtractGreen(pixels)
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+
+	// predictor transform, one mode for all blocks
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	blocksW, blocksH := subSampleSize(width, predictorBits), subSampleSize(height, predictorBits)
+	modes := make([]uint32, blocksW*blocksH)
+	for i := range modes {
+		modes[i] = predictorSelect << 8 // mode is in green
+	}
+	writeImage(bw, modes, blocksW, false)
+	predict(pixels, width)
+
+	bw.write(0, 1) // no more transforms
+	writeImage(bw, pixels, width, true)
+
+	data := bw.bytes()
+	size := len(data)
+	if size%2 == 1 {
+		data = append(data, 0)
+	}
+	var header bytes.Buffer
+	header.WriteString("RIFF")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(4+8+len(data)))
+	header.WriteString("WEBPVP8L")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(size))
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+const (
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	predictorBits   = 9  // 512x512 blocks
+	predictorSelect = 11 // select the left or top pixel closer to the gradient
+
+	numLiteralCodes  = 256
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+	maxLength        = 4096
+	minLength        = 3
+
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+)
+
+// codeLengthCodeOrder is the order of code lengths of the code length code.
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func subSampleSize(size, bits int) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+func subtractGreen(pixels []uint32) {
+	for i, p := range pixels {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		pixels[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predict replaces pixels with residuals of predictor select.
+func predict(pixels []uint32, width int) {
+	residuals := make([]uint32, len(pixels))
+	for i, p := range pixels {
+		x, y := i%width, i/width
+		var pred uint32
+		switch {
+		case i == 0:
+			pred = 0xff000000
+		case y == 0:
+			pred = pixels[i-1]
+		case x == 0:
+			pred = pixels[i-width]
+		default:
+			pred = selectPixel(pixels[i-1], pixels[i-width], pixels[i-width-1])
+		}
+		residuals[i] = subPixels(p, pred)
+	}
+	copy(pixels, residuals)
+}
+
+// selectPixel returns left or top pixel closer to the gradient estimate left + top - topLeft.
+func selectPixel(l, t, tl uint32) uint32 {
+	pl, pt := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		cl, ct, ctl := int(l>>shift&0xff), int(t>>shift&0xff), int(tl>>shift&0xff)
+		pl += abs(ct - ctl) // distance of estimate to left
+		pt += abs(cl - ctl) // distance of estimate to top
+	}
+	if pl < pt {
+		return l
+	}
+	return t
+}
+
+func subPixels(a, b uint32) uint32 {
+	var r uint32
+	for shift := 0; shift < 32; shift += 8 {
+		r |= ((a>>shift)&0xff - (b>>shift)&0xff) & 0xff << shift
+	}
+	return r
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// symbol is a literal pixel or a backward reference.
+type symbol struct {
+	pixel  uint32
+	length int // 0 for literal
+	dist   int // distance code, 1 for the top pixel and 2 for the left pixel
+}
+
+// writeImage writes entropy-coded pixels, isMain is false for sub-images of transforms.
+func writeImage(bw *bitWriter, pixels []uint32, width int, isMain bool) {
+	symbols := make([]symbol, 0, len(pixels))
+	for i := 0; i < len(pixels); {
+		left, top := 0, 0
+		if isMain && i >= 1 {
+			for i+left < len(pixels) && left < maxLength && pixels[i+left] == pixels[i+left-1] {
+				left++
+			}
+		}
+		if isMain && i >= width {
+			for i+top < len(pixels) && top < maxLength && pixels[i+top] == pixels[i+top-width] {
+				top++
+			}
+		}
+		switch {
+		case left >= minLength && left >= top:
+			symbols = append(symbols, symbol{length: left, dist: 2})
+			i += left
+		case top >= minLength:
+			symbols = append(symbols, symbol{length: top, dist: 1})
+			i += top
+		default:
+			symbols = append(symbols, symbol{pixel: pixels[i]})
+			i++
+		}
+	}
+
+	// prefix codes of green with lengths, red, blue, alpha and distance
+	freqs := [5][]int{
+		make([]int, numLiteralCodes+numLengthCodes),
+		make([]int, numLiteralCodes),
+		make([]int, numLiteralCodes),
+		make([]int, numLiteralCodes),
+		make([]int, numDistanceCodes),
+	}
+	for _, s := range symbols {
+		if s.length > 0 {
+			code, _, _ := prefixEncode(s.length)
+			freqs[0][numLiteralCodes+code]++
+			code, _, _ = prefixEncode(s.dist)
+			freqs[4][code]++
+			continue
+		}
+		freqs[0][s.pixel>>8&0xff]++
+		freqs[1][s.pixel>>16&0xff]++
+		freqs[2][s.pixel&0xff]++
+		freqs[3][s.pixel>>24]++
+	}
+
+	bw.write(0, 1) // no color cache
+	if isMain {
+		bw.write(0, 1) // no meta prefix codes
+	}
+	var codes [5]*prefixCode
+	for i := range codes {
+		codes[i] = newPrefixCode(freqs[i], maxCodeLength)
+		codes[i].writeHeader(bw)
+	}
+	for _, s := range symbols {
+		if s.length > 0 {
+			code, n, extra := prefixEncode(s.length)
+			codes[0].writeSymbol(bw, numLiteralCodes+code)
+			bw.write(extra, n)
+			code, n, extra = prefixEncode(s.dist)
+			codes[4].writeSymbol(bw, code)
+			bw.write(extra, n)
+			continue
+		}
+		codes[0].writeSymbol(bw, int(s.pixel>>8&0xff))
+		codes[1].writeSymbol(bw, int(s.pixel>>16&0xff))
+		codes[2].writeSymbol(bw, int(s.pixel&0xff))
+		codes[3].writeSymbol(bw, int(s.pixel>>24))
+	}
+}
+
+// prefixEncode returns the prefix code and extra bits of length or distance value from 1.
+func prefixEncode(v int) (code int, n uint, extra uint32) {
+	if v <= 4 {
+		return v - 1, 0, 0
+	}
+	d := v - 1
+	highest := bits.Len(uint(d)) - 1
+	second := (d >> (highest - 1)) & 1
+	n = uint(highest - 1)
+	return 2*highest + second, n, uint32(d & (1<<n - 1))
+}
+
+// prefixCode is a canonical huffman code.
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+	single  int // the only symbol coded without bits, -1 if not simple
+}
+
+func newPrefixCode(freqs []int, maxLen int) *prefixCode {
+	c := &prefixCode{single: -1}
+	used := 0
+	for s, f := range freqs {
+		if f > 0 {
+			used++
+			c.single = s
+		}
+	}
+	if used <= 1 {
+		if c.single < 0 {
+			c.single = 0
+		}
+		return c
+	}
+	c.single = -1
+	c.lengths = huffmanLengths(freqs, maxLen)
+	c.codes = canonicalCodes(c.lengths)
+	return c
+}
+
+// writeHeader writes the code lengths of the code.
+func (c *prefixCode) writeHeader(bw *bitWriter) {
+	if c.single >= 0 {
+		bw.write(1, 1) // simple code
+		bw.write(0, 1) // one symbol
+		if c.single < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(c.single), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(c.single), 8)
+		}
+		return
+	}
+	bw.write(0, 1) // normal code
+
+	// code lengths with runs of zeros
+	type token struct {
+		code  int
+		n     uint
+		extra uint32
+	}
+	var tokens []token
+	for i := 0; i < len(c.lengths); {
+		if c.lengths[i] != 0 {
+			tokens = append(tokens, token{code: c.lengths[i]})
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(c.lengths) && c.lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run < 3:
+			for j := 0; j < run; j++ {
+				tokens = append(tokens, token{code: 0})
+			}
+		case run <= 10:
+			tokens = append(tokens, token{code: 17, n: 3, extra: uint32(run - 3)})
+		default:
+			tokens = append(tokens, token{code: 18, n: 7, extra: uint32(run - 11)})
+		}
+		i += run
+	}
+
+	freqs := make([]int, len(codeLengthCodeOrder))
+	for _, t := range tokens {
+		freqs[t.code]++
+	}
+	lengthCode := newPrefixCode(freqs, maxCodeLengthCodeLength)
+	if lengthCode.single >= 0 {
+		// a code with one symbol is not allowed in code length code, add an unused symbol
+		other := 0
+		if lengthCode.single == 0 {
+			other = 1
+		}
+		lengthCode.lengths = make([]int, len(freqs))
+		lengthCode.lengths[lengthCode.single], lengthCode.lengths[other] = 1, 1
+		lengthCode.codes = canonicalCodes(lengthCode.lengths)
+		lengthCode.single = -1
+	}
+	n := 4
+	for i, s := range codeLengthCodeOrder {
+		if lengthCode.lengths[s] > 0 && i+1 > n {
+			n = i + 1
+		}
+	}
+	bw.write(uint32(n-4), 4)
+	for _, s := range codeLengthCodeOrder[:n] {
+		bw.write(uint32(lengthCode.lengths[s]), 3)
+	}
+	bw.write(0, 1) // code lengths of all symbols
+	for _, t := range tokens {
+		lengthCode.writeSymbol(bw, t.code)
+		bw.write(t.extra, t.n)
+	}
+}
+
+func (c *prefixCode) writeSymbol(bw *bitWriter, s int) {
+	if c.single >= 0 {
+		return
+	}
+	bw.write(c.codes[s], uint(c.lengths[s]))
+}
+
+// huffmanLengths returns code lengths of symbols by frequencies, no longer than maxLen.
+// Frequencies are flattened until the lengths fit.
+func huffmanLengths(freqs []int, maxLen int) []int {
+	freqs = append([]int(nil), freqs...)
+	for {
+		lengths := buildHuffman(freqs)
+		fit := true
+		for _, l := range lengths {
+			if l > maxLen {
+				fit = false
+				break
+			}
+		}
+		if fit {
+			return lengths
+		}
+		for i, f := range freqs {
+			if f > 0 {
+				freqs[i] = f/2 + 1
+			}
+		}
+	}
+}
+
+type huffmanNode struct {
+	freq        int
+	symbol      int // -1 for internal nodes
+	left, right *huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int            { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool  { return h[i].freq < h[j].freq }
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+func buildHuffman(freqs []int) []int {
+	h := &huffmanHeap{}
+	for s, f := range freqs {
+		if f > 0 {
+			*h = append(*h, &huffmanNode{freq: f, symbol: s})
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 1 {
+		a, b := heap.Pop(h).(*huffmanNode), heap.Pop(h).(*huffmanNode)
+		heap.Push(h, &huffmanNode{freq: a.freq + b.freq, symbol: -1, left: a, right: b})
+	}
+	lengths := make([]int, len(freqs))
+	var walk func(n *huffmanNode, depth int)
+	walk = func(n *huffmanNode, depth int) {
+		if n.symbol >= 0 {
+			lengths[n.symbol] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk((*h)[0], 0)
+	return lengths
+}
+
+// canonicalCodes returns canonical codes of lengths, bits are reversed to be written from the lowest bit.
+func canonicalCodes(lengths []int) []uint32 {
+	var count [maxCodeLength + 1]int
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+		}
+	}
+	var next [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + uint32(count[l-1])) << 1
+		next[l] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = bits.Reverse32(next[l]) >> (32 - l)
+			next[l]++
+		}
+	}
+	return codes
+}
+
+// bitWriter writes bits from the lowest bit of each byte.
+type bitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.n > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.n = 0, 0
+	}
+	return w.buf
+}
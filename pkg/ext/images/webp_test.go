@@ -0,0 +1,98 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func testImage(size image.Point) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x ^ y), A: 0xff}
+			if x > size.X/2 {
+				c = color.NRGBA{R: 10, G: 200, B: 30, A: uint8(128 + y)}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// checkWebP encodes img and checks the decoded image has the same pixels.
+func checkWebP(t *testing.T, name string, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	data := buf.Bytes()
+	decoded, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%s: decode: %v", name, err)
+	}
+	b := img.Bounds()
+	if decoded.Bounds().Size() != b.Size() {
+		t.Fatalf("%s: bounds %v", name, decoded.Bounds())
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			got := color.NRGBAModel.Convert(decoded.At(x, y))
+			want := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y))
+			if got != want {
+				t.Fatalf("%s: pixel (%d,%d) = %v, want %v", name, x, y, got, want)
+			}
+		}
+	}
+	return data
+}
+
+func TestEncodeWebP(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {3, 2}, {67, 41}, {600, 20}, {1 << 14, 1}, {1, 1 << 14}} {
+		checkWebP(t, fmt.Sprint(size), testImage(size))
+	}
+	// sub image does not start at origin
+	checkWebP(t, "sub image", testImage(image.Pt(40, 30)).SubImage(image.Rect(5, 7, 33, 21)))
+
+	for _, size := range []image.Point{{0, 1}, {1, 0}, {1<<14 + 1, 1}, {1, 1<<14 + 1}} {
+		if err := EncodeWebP(io.Discard, image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))); err == nil {
+			t.Fatalf("%v: invalid size should fail", size)
+		}
+	}
+}
+
+func TestEncodeWebPAlpha(t *testing.T) {
+	// alpha_is_used bit is after signature, width and height in VP8L header, which starts at byte 20
+	alphaUsed := func(data []byte) bool {
+		return data[20+4]&0x10 != 0
+	}
+
+	opaque := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(opaque, opaque.Bounds(), image.NewUniform(color.RGBA{R: 1, G: 2, B: 3, A: 0xff}), image.Point{}, draw.Src)
+	if alphaUsed(checkWebP(t, "opaque", opaque)) {
+		t.Fatal("opaque image should not use alpha")
+	}
+
+	// transparent pixels keep their colors in lossless webp
+	transparent := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 8; i++ {
+		transparent.SetNRGBA(i, i, color.NRGBA{R: uint8(i * 30), G: 40, B: 50, A: 0})
+		transparent.SetNRGBA(7-i, i, color.NRGBA{R: 60, G: 70, B: 80, A: uint8(i * 36)})
+	}
+	if !alphaUsed(checkWebP(t, "transparent", transparent)) {
+		t.Fatal("transparent image should use alpha")
+	}
+
+	// premultiplied colors are converted to non-premultiplied
+	checkWebP(t, "premultiplied", &image.RGBA{
+		Pix:    []byte{0x40, 0x20, 0x10, 0x80, 0, 0, 0, 0},
+		Stride: 8,
+		Rect:   image.Rect(0, 0, 2, 1),
+	})
+}
@@ -0,0 +1,91 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExternalTimeout is the timeout of requesting one external link.
+var ExternalTimeout = 10 * time.Second
+
+// checkExternal requests each external url once,
+// problems of pages linking to a broken url are returned as warnings.
+func checkExternal(c context.Context, external map[string][]*Problem, jobs int) []*Problem {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	urls := make([]string, 0, len(external))
+	for u := range external {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	var (
+		client   = &http.Client{Timeout: ExternalTimeout}
+		lock     sync.Mutex
+		problems []*Problem
+		wg       sync.WaitGroup
+		queue    = make(chan string)
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				err := requestURL(c, client, u)
+				if err == nil {
+					continue
+				}
+				lock.Lock()
+				for _, p := range external[u] {
+					p.Message = fmt.Sprintf("broken external link: %s, %s", p.URL, err)
+					p.Warning = true
+					problems = append(problems, p)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, u := range urls {
+		if c.Err() != nil {
+			break
+		}
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+	return problems
+}
+
+// requestURL requests u with HEAD, and GET if HEAD is not allowed.
+func requestURL(c context.Context, client *http.Client, u string) error {
+	status, err := doRequest(c, client, http.MethodHead, u)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = doRequest(c, client, http.MethodGet, u)
+	}
+	if err != nil {
+		return err
+	}
+	if status >= 400 {
+		return fmt.Errorf("status %d", status)
+	}
+	return nil
+}
+
+func doRequest(c context.Context, client *http.Client, method, u string) (int, error) {
+	req, err := http.NewRequestWithContext(c, method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,239 @@
+// Package linkcheck finds broken links, missing anchors and missing assets in generated html.
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	phtml "github.com/tdewolff/parse/v2/html"
+)
+
+// Page is a generated html page to check.
+type Page struct {
+	Path   string // slash path relative to output directory
+	Link   string
+	Source string // source content file, empty for generated pages
+	Data   []byte
+}
+
+// Problem is a broken reference found in a page.
+type Problem struct {
+	Page    *Page
+	Line    int    // line in page html
+	URL     string // href or src in page
+	Message string
+	Warning bool // missing anchors and broken external links are warnings
+}
+
+// Params is the params for checking pages.
+type Params struct {
+	BaseURL  string   // site base url, links to it are internal
+	Pages    []*Page  // html pages to check
+	Files    []string // all files in output directory, slash paths relative to it
+	External bool     // if true, external links are requested
+	Jobs     int      // number of parallel requests of external links
+}
+
+// ref is a link or asset reference in page.
+type ref struct {
+	url   string
+	line  int
+	asset bool // img, script or stylesheet
+}
+
+// parsedPage is a page with its references and element ids.
+type parsedPage struct {
+	*Page
+	refs []*ref
+	ids  map[string]bool
+}
+
+// Check checks all pages in params, external links are checked only if params.External is set.
+func Check(c context.Context, params *Params) ([]*Problem, error) {
+	base, err := url.Parse(params.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	basePath := strings.TrimSuffix(base.Path, "/")
+
+	files := make(map[string]bool, len(params.Files))
+	for _, f := range params.Files {
+		files[f] = true
+	}
+	pages := make(map[string]*parsedPage, len(params.Pages))
+	for _, p := range params.Pages {
+		pages[p.Path] = parsePage(p)
+	}
+
+	var (
+		problems []*Problem
+		external = make(map[string][]*Problem)
+	)
+	for _, p := range params.Pages {
+		pg := pages[p.Path]
+		pageURL := base.ResolveReference(&url.URL{Path: basePath + "/" + strings.TrimPrefix(p.Link, "/")})
+		for _, r := range pg.refs {
+			problem := &Problem{Page: p, Line: r.line, URL: r.url}
+			u, err := url.Parse(strings.TrimSpace(r.url))
+			if err != nil {
+				problem.Message = "invalid url: " + err.Error()
+				problems = append(problems, problem)
+				continue
+			}
+			if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+				continue // mailto, tel, data and so on
+			}
+			target := pageURL.ResolveReference(u)
+			if target.Host != base.Host || !strings.HasPrefix(target.Path+"/", basePath+"/") {
+				if params.External {
+					request := *target
+					request.Fragment = ""
+					key := request.String()
+					external[key] = append(external[key], problem)
+				}
+				continue
+			}
+
+			file := findFile(files, strings.TrimPrefix(target.Path, basePath))
+			switch {
+			case file == "" && r.asset:
+				problem.Message = "missing asset: " + r.url
+			case file == "":
+				problem.Message = "broken link: " + r.url
+			case target.Fragment != "" && !r.asset:
+				// anchors are only known in pages rendered in this check
+				if tp := pages[file]; tp != nil && !tp.ids[target.Fragment] {
+					problem.Message = "missing anchor: " + r.url
+					problem.Warning = true
+				}
+			}
+			if problem.Message != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if len(external) > 0 {
+		problems = append(problems, checkExternal(c, external, params.Jobs)...)
+	}
+	return problems, c.Err()
+}
+
+// findFile returns the output file of url path, or empty if not found.
+func findFile(files map[string]bool, urlPath string) string {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name != "" && files[name] {
+		return name
+	}
+	index := path.Join(name, "index.html")
+	if files[index] {
+		return index
+	}
+	return ""
+}
+
+// parsePage finds links, assets and element ids in page html.
+func parsePage(p *Page) *parsedPage {
+	pg := &parsedPage{Page: p, ids: make(map[string]bool)}
+	input := parse.NewInputBytes(p.Data)
+	lexer := phtml.NewLexer(input)
+
+	var (
+		tag     string
+		rel     string
+		hrefRef *ref
+		line    = 1
+		last    = 0
+	)
+	for {
+		tt, _ := lexer.Next()
+		if tt == phtml.ErrorToken {
+			break
+		}
+		// count lines of data consumed by the lexer
+		if offset := input.Offset(); offset > last && offset <= len(p.Data) {
+			line += bytes.Count(p.Data[last:offset], []byte("\n"))
+			last = offset
+		}
+		switch tt {
+		case phtml.StartTagToken:
+			tag, rel, hrefRef = strings.ToLower(string(lexer.Text())), "", nil
+		case phtml.AttributeToken:
+			name := strings.ToLower(string(lexer.Text()))
+			value := attrValue(lexer.AttrVal())
+			switch {
+			case name == "id" || (name == "name" && tag == "a"):
+				pg.ids[value] = true
+			case name == "rel" && tag == "link":
+				rel = strings.ToLower(value)
+				// href is before rel
+				if hrefRef != nil && isLinkHint(rel) {
+					pg.refs = pg.refs[:len(pg.refs)-1]
+					hrefRef = nil
+				} else if hrefRef != nil {
+					hrefRef.asset = isLinkAsset(rel)
+				}
+			case name == "href" && (tag == "a" || tag == "link"):
+				if value == "" || value == "#" || (tag == "link" && isLinkHint(rel)) {
+					continue
+				}
+				hrefRef = &ref{url: value, line: line, asset: tag == "link" && (rel == "" || isLinkAsset(rel))}
+				pg.refs = append(pg.refs, hrefRef)
+			case name == "src" && (tag == "img" || tag == "script" || tag == "source" || tag == "video" || tag == "audio"):
+				if value != "" {
+					pg.refs = append(pg.refs, &ref{url: value, line: line, asset: true})
+				}
+			case name == "srcset" && (tag == "img" || tag == "source"):
+				for _, src := range srcsetURLs(value) {
+					pg.refs = append(pg.refs, &ref{url: src, line: line, asset: true})
+				}
+			}
+		}
+	}
+	return pg
+}
+
+// attrValue returns the unquoted and unescaped attribute value.
+func attrValue(val []byte) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		val = val[1 : len(val)-1]
+	}
+	return html.UnescapeString(string(val))
+}
+
+// isLinkAsset returns whether the link element with rel loads a file for the page.
+func isLinkAsset(rel string) bool {
+	for _, r := range strings.Fields(rel) {
+		switch r {
+		case "stylesheet", "icon", "preload", "manifest", "apple-touch-icon":
+			return true
+		}
+	}
+	return false
+}
+
+// isLinkHint returns whether the link element with rel only names a host.
+func isLinkHint(rel string) bool {
+	for _, r := range strings.Fields(rel) {
+		if r == "preconnect" || r == "dns-prefetch" {
+			return true
+		}
+	}
+	return false
+}
+
+// srcsetURLs returns urls in srcset, such as "a.jpg 1x, b.jpg 2x".
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
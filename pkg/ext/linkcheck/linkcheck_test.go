@@ -0,0 +1,69 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	page := &Page{
+		Path: "post/index.html",
+		Link: "/post/",
+		Data: []byte(`<html><head>
+<link rel="stylesheet" href="/css/site.css">
+<link href="https://fonts.example.com" rel="preconnect">
+</head><body>
+<h2 id="intro">Intro</h2>
+<a href="#intro">ok</a>
+<a href="../about/">ok</a>
+<a href="https://example.com/post/">ok</a>
+<a href="mailto:a@example.com">ok</a>
+<a href="/missing/">broken</a>
+<a href="/about/#team">anchor</a>
+<img src="a.png" srcset="a.png 1x, b.png 2x">
+<a href="` + server.URL + `/ok">ok</a>
+<a href="` + server.URL + `/gone#top">gone</a>
+</body></html>`),
+	}
+	about := &Page{Path: "about/index.html", Link: "/about/", Data: []byte(`<h1 id="about">About</h1>`)}
+	problems, err := Check(context.Background(), &Params{
+		BaseURL:  "https://example.com",
+		Pages:    []*Page{page, about},
+		Files:    []string{"post/index.html", "post/a.png", "about/index.html", "css/site.css"},
+		External: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Message)
+	}
+	sort.Strings(got)
+	want := []string{
+		"broken external link: " + server.URL + "/gone#top, status 404",
+		"broken link: /missing/",
+		"missing anchor: /about/#team",
+		"missing asset: b.png",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, p := range problems {
+		if p.URL == "/missing/" && p.Line != 10 {
+			t.Fatalf("broken link should be at line 10, got %d", p.Line)
+		}
+	}
+}
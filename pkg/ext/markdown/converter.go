@@ -3,35 +3,91 @@ package markdown
 import (
 	"bytes"
 	"io"
+	"pugo/pkg/ext/highlight"
+	"pugo/pkg/ext/shortcode"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
 )
 
-// ConvertFunc is the markdown function.
-type ConvertFunc func(source []byte, writer io.Writer) error
+// ConvertFunc converts markdown source to html, it returns headings in source.
+type ConvertFunc func(source []byte, writer io.Writer) ([]*Heading, error)
 
-var (
-	globalMarkdown goldmark.Markdown = nil
-)
+// Heading is a heading in markdown, ID is generated from text if not set.
+type Heading struct {
+	Level int
+	ID    string
+	Text  string
+}
 
-// Get gets markdown converter function.
-func Get() ConvertFunc {
-	return func(source []byte, writer io.Writer) error {
-		return getMarkdown().Convert(source, writer)
-	}
+// headingsKey is the key of headings collected in parser context.
+var headingsKey = parser.NewContextKey()
+
+// Options is the options of converter, nil options use the defaults.
+type Options struct {
+	Image     ImageFunc         // returns responsive sources of images, images are rendered as they are if nil
+	Highlight *highlight.Config // highlights fenced code blocks if enabled
+	Shortcode shortcode.Func    // renders shortcodes expanded before converting, shortcodes are kept as text if nil
 }
 
-func getMarkdown() goldmark.Markdown {
-	if globalMarkdown == nil {
-		globalMarkdown = NewMarkdown()
+// NewConvertFunc returns converter function of a new markdown instance,
+// it is safe for concurrent use.
+func NewConvertFunc(opts *Options) ConvertFunc {
+	md := NewMarkdown()
+	if opts != nil && opts.Highlight != nil && opts.Highlight.Enabled {
+		md.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(&codeRenderer{cfg: opts.Highlight}, 100),
+		))
 	}
-	return globalMarkdown
+	if opts != nil && opts.Image != nil {
+		md.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(&imageRenderer{fn: opts.Image}, 100),
+		))
+	}
+	var convert ConvertFunc
+	convert = func(source []byte, writer io.Writer) ([]*Heading, error) {
+		var expanded *shortcode.Expanded
+		if opts != nil && opts.Shortcode != nil {
+			e, err := shortcode.Expand(source, &shortcode.Params{
+				Render: opts.Shortcode,
+				Markdown: func(inner string) (string, error) {
+					var buf bytes.Buffer
+					_, err := convert([]byte(inner), &buf)
+					return buf.String(), err
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			expanded, source = e, e.Source
+		}
+
+		pc := parser.NewContext()
+		doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(pc))
+		if expanded == nil {
+			if err := md.Renderer().Render(writer, source, doc); err != nil {
+				return nil, err
+			}
+		} else {
+			// rendered shortcodes are put back after converting
+			var buf bytes.Buffer
+			if err := md.Renderer().Render(&buf, source, doc); err != nil {
+				return nil, err
+			}
+			if _, err := writer.Write(expanded.Restore(buf.Bytes())); err != nil {
+				return nil, err
+			}
+		}
+		headings, _ := pc.Get(headingsKey).([]*Heading)
+		return headings, nil
+	}
+	return convert
 }
 
 // NewMarkdown returns a new goldmark.Markdown instance.
@@ -76,6 +132,15 @@ func (g *astTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 					break
 				}
 			}
+		case *ast.Heading:
+			heading := &Heading{Level: v.Level, Text: string(v.Text(reader.Source()))}
+			if id, ok := v.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					heading.ID = string(b)
+				}
+			}
+			headings, _ := pc.Get(headingsKey).([]*Heading)
+			pc.Set(headingsKey, append(headings, heading))
 		}
 		return ast.WalkContinue, nil
 	})
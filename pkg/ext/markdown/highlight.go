@@ -0,0 +1,42 @@
+package markdown
+
+import (
+	"bytes"
+	"pugo/pkg/ext/highlight"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeRenderer renders fenced code blocks as highlighted html.
+type codeRenderer struct {
+	cfg *highlight.Config
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *codeRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	var info string
+	if n.Info != nil {
+		info = string(n.Info.Segment.Value(source))
+	}
+	lang, lines := highlight.ParseInfo(info)
+
+	var code bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+	if err := highlight.Write(w, code.String(), lang, lines, r.cfg); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// ImageSet is the responsive sources of an image.
+type ImageSet struct {
+	Width      int    // width of original image
+	Height     int    // height of original image
+	Srcset     string // resized images in original format, such as "/a-480w.png 480w, /a.png 960w"
+	WebPSrcset string // images in webp format, or original format in sizes webp is larger, empty if not converted
+	Sizes      string // sizes attribute of srcset
+	Lazy       bool   // if true, load the image lazily
+}
+
+// ImageFunc returns the image set of image src in markdown, nil to render the image as it is.
+type ImageFunc func(src string) *ImageSet
+
+// imageRenderer renders images with image sets as pictures.
+type imageRenderer struct {
+	fn ImageFunc
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *imageRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, r.renderImage)
+}
+
+func (r *imageRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	set := r.fn(string(n.Destination))
+	if set != nil && set.WebPSrcset != "" {
+		_, _ = w.WriteString(`<picture><source type="image/webp"`)
+		writeAttr(w, "srcset", set.WebPSrcset)
+		writeAttr(w, "sizes", set.Sizes)
+		_, _ = w.WriteString(" />")
+	}
+
+	// the same as img of goldmark html renderer, which is unsafe and xhtml
+	_, _ = w.WriteString("<img src=\"")
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(n.Destination, true)))
+	_, _ = w.WriteString(`" alt="`)
+	_, _ = w.Write(util.EscapeHTML(n.Text(source)))
+	_ = w.WriteByte('"')
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		html.DefaultWriter.Write(w, n.Title)
+		_ = w.WriteByte('"')
+	}
+	if set != nil {
+		if set.Srcset != "" {
+			writeAttr(w, "srcset", set.Srcset)
+			writeAttr(w, "sizes", set.Sizes)
+		}
+		writeAttr(w, "width", strconv.Itoa(set.Width))
+		writeAttr(w, "height", strconv.Itoa(set.Height))
+		if set.Lazy {
+			writeAttr(w, "loading", "lazy")
+		}
+	}
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, n, html.ImageAttributeFilter)
+	}
+	_, _ = w.WriteString(" />")
+	if set != nil && set.WebPSrcset != "" {
+		_, _ = w.WriteString("</picture>")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeAttr writes attribute with escaped value, empty value is skipped.
+func writeAttr(w util.BufWriter, name, value string) {
+	if value == "" {
+		return
+	}
+	_, _ = w.WriteString(" " + name + "=\"")
+	_, _ = w.Write(util.EscapeHTML([]byte(value)))
+	_ = w.WriteByte('"')
+}
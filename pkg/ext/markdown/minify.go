@@ -5,33 +5,33 @@ import (
 	"github.com/tdewolff/minify/v2/html"
 )
 
-var (
-	globalMinifier *minify.M = nil
-)
+// Minifier minifies html outputs, nil minifier keeps outputs as they are.
+type Minifier struct {
+	m *minify.M
+}
 
-// InitMinfier initializes minifier
-func InitMinfier(flag bool) {
-	if flag {
-		m := minify.New()
-		m.Add("text/html", &html.Minifier{
-			KeepComments:            false,
-			KeepConditionalComments: true,
-			KeepDefaultAttrVals:     true,
-			KeepDocumentTags:        true,
-			KeepEndTags:             false,
-			KeepQuotes:              true,
-			KeepWhitespace:          false,
-		})
-		globalMinifier = m
-	} else {
-		globalMinifier = nil
+// NewMinifier returns a new minifier, it returns nil if not enabled.
+func NewMinifier(enabled bool) *Minifier {
+	if !enabled {
+		return nil
 	}
+	m := minify.New()
+	m.Add("text/html", &html.Minifier{
+		KeepComments:            false,
+		KeepConditionalComments: true,
+		KeepDefaultAttrVals:     true,
+		KeepDocumentTags:        true,
+		KeepEndTags:             false,
+		KeepQuotes:              true,
+		KeepWhitespace:          false,
+	})
+	return &Minifier{m: m}
 }
 
 // MinifyHTML minifies HTML
-func MinifyHTML(raw []byte) ([]byte, error) {
-	if globalMinifier == nil {
+func (m *Minifier) MinifyHTML(raw []byte) ([]byte, error) {
+	if m == nil {
 		return raw, nil
 	}
-	return globalMinifier.Bytes("text/html", raw)
+	return m.m.Bytes("text/html", raw)
 }
@@ -0,0 +1,33 @@
+package redirect
+
+import "net/http"
+
+type Config struct {
+	Enabled     bool    `toml:"enabled"`      // write html stubs redirecting by meta refresh
+	NetlifyFile string  `toml:"netlify_file"` // link of Netlify style redirects file, such as "/_redirects", disabled if empty
+	NginxFile   string  `toml:"nginx_file"`   // link of nginx map file, such as "/redirects.map", other status are in "/redirects.302.map", disabled if empty
+	Status      int     `toml:"status"`       // status code in redirects files
+	Rules       []*Rule `toml:"rules"`        // site level redirects, besides aliases of posts and pages
+}
+
+// Rule is a redirect in config.
+type Rule struct {
+	From   string `toml:"from"`
+	To     string `toml:"to"` // link or full url
+	Status int    `toml:"status"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled: true,
+		Status:  http.StatusMovedPermanently,
+	}
+}
+
+// GetStatus returns the status code of redirects without own status.
+func (c *Config) GetStatus() int {
+	if c.Status < 300 || c.Status > 399 {
+		return http.StatusMovedPermanently
+	}
+	return c.Status
+}
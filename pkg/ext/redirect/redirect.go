@@ -0,0 +1,162 @@
+// Package redirect renders redirects of old links, as html stubs and redirects files of servers.
+package redirect
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Redirect is an old link redirecting to a new link.
+type Redirect struct {
+	From   string // link in site, such as "/2022/02/hello/"
+	To     string // link in site or full url
+	Status int    // use the status of config if zero
+}
+
+type RenderParams struct {
+	Config      *Config
+	Redirects   []*Redirect // links should be cleaned by CleanLink
+	SiteBaseURL string
+	OutputDir   string
+}
+
+var stubTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Redirecting to {{.URL}}</title>
+<link rel="canonical" href="{{.URL}}">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{.URL}}">
+</head>
+<body>
+<p>This page has moved to <a href="{{.URL}}">{{.URL}}</a>.</p>
+</body>
+</html>
+`))
+
+// CleanLink returns the link with leading slash, or empty if link is not in site.
+func CleanLink(link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" || strings.Contains(link, "://") || strings.HasPrefix(link, "//") {
+		return ""
+	}
+	cleaned := path.Clean("/" + link)
+	// keep trailing slash of directory links
+	if strings.HasSuffix(link, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// StubFile returns the slash path of html stub of link in output directory.
+func StubFile(link string) string {
+	return strings.TrimPrefix(filepath.ToSlash(utils.FormatIndexHTML(link)), "/")
+}
+
+// Render renders html stubs and redirects files enabled in config.
+func Render(params *RenderParams) ([]*models.OutputFile, error) {
+	if params == nil || params.Config == nil || len(params.Redirects) == 0 {
+		return nil, nil
+	}
+	var outputs []*models.OutputFile
+	if params.Config.Enabled {
+		for _, r := range params.Redirects {
+			buf := bytes.NewBuffer(nil)
+			data := map[string]string{"URL": fullURL(params.SiteBaseURL, r.To)}
+			if err := stubTemplate.Execute(buf, data); err != nil {
+				return nil, fmt.Errorf("failed to render redirect %s: %w", r.From, err)
+			}
+			outputs = append(outputs, &models.OutputFile{
+				Path: filepath.Join(params.OutputDir, StubFile(r.From)),
+				Link: r.From,
+				Buf:  buf,
+			})
+		}
+	}
+	if link := params.Config.NetlifyFile; link != "" {
+		buf := bytes.NewBuffer(nil)
+		for _, r := range params.Redirects {
+			fmt.Fprintf(buf, "%s %s %d\n", r.From, r.To, status(params.Config, r))
+		}
+		outputs = append(outputs, &models.OutputFile{Path: filepath.Join(params.OutputDir, link), Link: link, Buf: buf})
+	}
+	if link := params.Config.NginxFile; link != "" {
+		outputs = append(outputs, renderNginx(params, link)...)
+	}
+	return outputs, nil
+}
+
+// renderNginx renders nginx map files, status code can not be a variable in nginx,
+// so redirects of status other than the config are in files of each status, such as "redirects.302.map".
+func renderNginx(params *RenderParams, link string) []*models.OutputFile {
+	defaultStatus := params.Config.GetStatus()
+	bufs := map[int]*bytes.Buffer{defaultStatus: {}}
+	for _, r := range params.Redirects {
+		s := status(params.Config, r)
+		if bufs[s] == nil {
+			bufs[s] = &bytes.Buffer{}
+		}
+		fmt.Fprintf(bufs[s], "%s %s;\n", nginxQuote(r.From), nginxQuote(r.To))
+	}
+	statuses := make([]int, 0, len(bufs))
+	for s := range bufs {
+		statuses = append(statuses, s)
+	}
+	sort.Ints(statuses)
+
+	links := make(map[int]string, len(bufs))
+	var maps, returns strings.Builder
+	for _, s := range statuses {
+		links[s] = link
+		if s != defaultStatus {
+			ext := path.Ext(link)
+			links[s] = strings.TrimSuffix(link, ext) + "." + strconv.Itoa(s) + ext
+		}
+		fmt.Fprintf(&maps, "# map $uri $redirect_%d { include %s; }\n", s, strings.TrimPrefix(links[s], "/"))
+		fmt.Fprintf(&returns, "# if ($redirect_%d) { return %d $redirect_%d; }\n", s, s, s)
+	}
+
+	var outputs []*models.OutputFile
+	for _, s := range statuses {
+		buf := bufs[s]
+		if s == defaultStatus {
+			buf = bytes.NewBufferString("# use in nginx http block:\n" + maps.String() +
+				"# and in server block:\n" + returns.String())
+			buf.Write(bufs[s].Bytes())
+		}
+		outputs = append(outputs, &models.OutputFile{Path: filepath.Join(params.OutputDir, links[s]), Link: links[s], Buf: buf})
+	}
+	return outputs
+}
+
+func status(cfg *Config, r *Redirect) int {
+	if r.Status >= 300 && r.Status <= 399 {
+		return r.Status
+	}
+	return cfg.GetStatus()
+}
+
+// fullURL returns the full url of link in site, other urls are not changed.
+func fullURL(base, link string) string {
+	if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+		return utils.FullURL(base, link)
+	}
+	return link
+}
+
+// nginxQuote quotes s if it has characters special in nginx config.
+func nginxQuote(s string) string {
+	if strings.ContainsAny(s, " \t;{}\"'#") {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return s
+}
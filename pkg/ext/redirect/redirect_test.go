@@ -0,0 +1,68 @@
+package redirect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanLink(t *testing.T) {
+	for link, expected := range map[string]string{
+		"old/post/":            "/old/post/",
+		" /a/../b.html ":       "/b.html",
+		"/":                    "/",
+		"":                     "",
+		"https://example.com/": "",
+		"//example.com/a":      "",
+	} {
+		if got := CleanLink(link); got != expected {
+			t.Fatalf("CleanLink(%q) = %q, expected %q", link, got, expected)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NetlifyFile = "/_redirects"
+	cfg.NginxFile = "/redirects.map"
+	files, err := Render(&RenderParams{
+		Config: cfg,
+		Redirects: []*Redirect{
+			{From: "/old/", To: "/new/"},
+			{From: "/tmp/", To: "https://example.org/a b", Status: 302},
+		},
+		SiteBaseURL: "https://example.com",
+		OutputDir:   "build",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := make(map[string]string)
+	for _, f := range files {
+		outputs[f.Link] = f.Buf.String()
+	}
+
+	if stub := outputs["/old/"]; !strings.Contains(stub, `content="0; url=https://example.com/new/"`) {
+		t.Fatalf("stub should redirect to full url: %s", stub)
+	}
+	if netlify := outputs["/_redirects"]; netlify != "/old/ /new/ 301\n/tmp/ https://example.org/a b 302\n" {
+		t.Fatalf("unexpected netlify redirects: %q", netlify)
+	}
+
+	nginx := outputs["/redirects.map"]
+	for _, s := range []string{
+		"# map $uri $redirect_301 { include redirects.map; }",
+		"# map $uri $redirect_302 { include redirects.302.map; }",
+		"# if ($redirect_302) { return 302 $redirect_302; }",
+		"\n/old/ /new/;\n",
+	} {
+		if !strings.Contains(nginx, s) {
+			t.Fatalf("nginx map should contain %q: %s", s, nginx)
+		}
+	}
+	if strings.Contains(nginx, "/tmp/") {
+		t.Fatalf("302 redirect should not be in 301 map: %s", nginx)
+	}
+	if m := outputs["/redirects.302.map"]; m != "/tmp/ \"https://example.org/a b\";\n" {
+		t.Fatalf("unexpected 302 map: %q", m)
+	}
+}
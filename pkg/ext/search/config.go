@@ -0,0 +1,50 @@
+package search
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultMaxContentLength = 3000
+)
+
+type Config struct {
+	Enabled          bool   `toml:"enabled"`
+	Link             string `toml:"link"`
+	IncludePages     bool   `toml:"include_pages"`
+	MaxContentLength int    `toml:"max_content_length"` // max characters of plain text body in each entry
+	ShardSize        int    `toml:"shard_size"`         // max entries in each shard file, not sharded if zero
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:          false,
+		Link:             "/search.json",
+		IncludePages:     true,
+		MaxContentLength: DefaultMaxContentLength,
+	}
+}
+
+// GetMaxContentLength returns the max characters of body in each entry.
+func (c *Config) GetMaxContentLength() int {
+	if c.MaxContentLength <= 0 {
+		return DefaultMaxContentLength
+	}
+	return c.MaxContentLength
+}
+
+// ShardLink returns the link of shard file by index from 1.
+func (c *Config) ShardLink(n int) string {
+	ext := path.Ext(c.Link)
+	return strings.TrimSuffix(c.Link, ext) + "-" + strconv.Itoa(n) + ext
+}
+
+// ShardsCount returns the number of shard files for entries, zero if not sharded.
+func (c *Config) ShardsCount(entries int) int {
+	if c.ShardSize <= 0 || entries <= c.ShardSize {
+		return 0
+	}
+	return (entries + c.ShardSize - 1) / c.ShardSize
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"pugo/pkg/core/models"
+	"pugo/pkg/utils"
+	"strings"
+	"time"
+)
+
+type (
+	// Entry is a searchable post or page in index.
+	Entry struct {
+		Title   string   `json:"title"`
+		Tags    []string `json:"tags,omitempty"`
+		Content string   `json:"content"`
+		Link    string   `json:"link"`
+		Date    string   `json:"date"`
+		Type    string   `json:"type"`
+	}
+
+	// Index is the content of search index file.
+	// If index is sharded, it contains shard links only, and entries are in each shard file.
+	Index struct {
+		Shards  []string `json:"shards,omitempty"`
+		Entries []*Entry `json:"entries,omitempty"`
+	}
+)
+
+// RenderParams represents the parameters for rendering the search index.
+type RenderParams struct {
+	Config    *Config
+	Posts     []*models.Post
+	Pages     []*models.Page
+	OutputDir string
+}
+
+// Render renders the search index, posts and pages should be converted.
+func Render(params *RenderParams) ([]*models.OutputFile, error) {
+	if params == nil || params.Config == nil || !params.Config.Enabled {
+		return nil, nil
+	}
+	maxLength := params.Config.GetMaxContentLength()
+	var entries []*Entry
+	for _, p := range params.Posts {
+		entries = append(entries, buildEntry(p, "post", maxLength))
+	}
+	if params.Config.IncludePages {
+		for _, pg := range params.Pages {
+			entries = append(entries, buildEntry(&pg.Post, "page", maxLength))
+		}
+	}
+
+	// not sharded, all entries in one file
+	shards := params.Config.ShardsCount(len(entries))
+	if shards == 0 {
+		out, err := renderIndex(params.OutputDir, params.Config.Link, &Index{Entries: entries})
+		if err != nil {
+			return nil, err
+		}
+		return []*models.OutputFile{out}, nil
+	}
+
+	index := &Index{}
+	outputs := make([]*models.OutputFile, 0, shards+1)
+	for i := 0; i < shards; i++ {
+		start, end := i*params.Config.ShardSize, (i+1)*params.Config.ShardSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		link := params.Config.ShardLink(i + 1)
+		out, err := renderIndex(params.OutputDir, link, &Index{Entries: entries[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		index.Shards = append(index.Shards, link)
+		outputs = append(outputs, out)
+	}
+	out, err := renderIndex(params.OutputDir, params.Config.Link, index)
+	if err != nil {
+		return nil, err
+	}
+	return append(outputs, out), nil
+}
+
+func buildEntry(p *models.Post, typ string, maxLength int) *Entry {
+	content := strings.Join(strings.Fields(utils.StripHTML(p.Content())), " ")
+	if runes := []rune(content); len(runes) > maxLength {
+		content = string(runes[:maxLength])
+	}
+	return &Entry{
+		Title:   p.Title,
+		Tags:    p.Tags,
+		Content: content,
+		Link:    p.Link,
+		Date:    p.Date().Format(time.RFC3339),
+		Type:    typ,
+	}
+}
+
+func renderIndex(outputDir, link string, index *Index) (*models.OutputFile, error) {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal search index %s: %w", link, err)
+	}
+	return &models.OutputFile{
+		Path: filepath.Join(outputDir, link),
+		Link: link,
+		Buf:  bytes.NewBuffer(data),
+	}, nil
+}
@@ -0,0 +1,113 @@
+package shortcode
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Builtins returns built-in shortcodes by names.
+func Builtins() map[string]Func {
+	return map[string]Func{
+		"figure":   figure,
+		"youtube":  youtube,
+		"gist":     gist,
+		"note":     admonition("note", "Note"),
+		"warning":  admonition("warning", "Warning"),
+		"raw-html": rawHTML,
+	}
+}
+
+// figure renders {{< figure src="a.png" alt="" caption="" title="" link="" class="" >}},
+// the image is converted as markdown, so it is processed as other images in contents.
+func figure(sc *Shortcode) (string, error) {
+	src := sc.Get("src", 0)
+	if src == "" {
+		return "", errors.New("src is missing")
+	}
+	image := "![" + sc.Get("alt", -1) + "](<" + src + ">"
+	if title := sc.Get("title", -1); title != "" {
+		image += ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+	}
+	image += ")"
+	img, err := sc.Markdown(image)
+	if err != nil {
+		return "", err
+	}
+	img = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(img), "<p>"), "</p>")
+
+	var b strings.Builder
+	b.WriteString("<figure")
+	if class := sc.Get("class", -1); class != "" {
+		b.WriteString(` class="` + html.EscapeString(class) + `"`)
+	}
+	b.WriteString(">")
+	if link := sc.Get("link", -1); link != "" {
+		b.WriteString(`<a href="` + html.EscapeString(link) + `">` + img + "</a>")
+	} else {
+		b.WriteString(img)
+	}
+	if caption := sc.Get("caption", -1); caption != "" {
+		b.WriteString("<figcaption>" + html.EscapeString(caption) + "</figcaption>")
+	}
+	b.WriteString("</figure>")
+	return b.String(), nil
+}
+
+var reVideoID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// youtube renders {{< youtube id title="" >}} as an embedded video without cookies.
+func youtube(sc *Shortcode) (string, error) {
+	id := sc.Get("id", 0)
+	if !reVideoID.MatchString(id) {
+		return "", errors.New("invalid video id: " + id)
+	}
+	title := sc.Get("title", 1)
+	if title == "" {
+		title = "YouTube video"
+	}
+	return `<div class="video"><iframe src="https://www.youtube-nocookie.com/embed/` + id + `" title="` + html.EscapeString(title) +
+		`" loading="lazy" allow="accelerometer; clipboard-write; encrypted-media; gyroscope; picture-in-picture" allowfullscreen></iframe></div>`, nil
+}
+
+var (
+	reGistPart   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	reGistAnchor = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+)
+
+// gist renders {{< gist user id file >}} as a link to the gist, which needs no scripts.
+func gist(sc *Shortcode) (string, error) {
+	user, id, file := sc.Get("user", 0), sc.Get("id", 1), sc.Get("file", 2)
+	if !reGistPart.MatchString(user) || !reGistPart.MatchString(id) {
+		return "", errors.New("user and id are required")
+	}
+	link := "https://gist.github.com/" + user + "/" + id
+	text := user + "/" + id
+	if file != "" {
+		// anchor of file in gist page, such as "file-main-go"
+		link += "#file-" + strings.ToLower(reGistAnchor.ReplaceAllString(file, "-"))
+		text += "/" + file
+	}
+	return `<p class="gist"><a href="` + html.EscapeString(link) + `" target="_blank">Gist: ` + html.EscapeString(text) + `</a></p>`, nil
+}
+
+// admonition returns the shortcode of callout, such as {{< note title="" >}}markdown{{< /note >}}.
+func admonition(class, defaultTitle string) Func {
+	return func(sc *Shortcode) (string, error) {
+		title := sc.Get("title", 0)
+		if title == "" {
+			title = defaultTitle
+		}
+		inner, err := sc.InnerHTML()
+		if err != nil {
+			return "", err
+		}
+		return `<div class="admonition ` + class + `"><p class="admonition-title">` + html.EscapeString(title) + "</p>" + string(inner) + "</div>", nil
+	}
+}
+
+// rawHTML renders {{< raw-html >}}html{{< /raw-html >}} as it is.
+func rawHTML(sc *Shortcode) (string, error) {
+	return sc.Inner, nil
+}
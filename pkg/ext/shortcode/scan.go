@@ -0,0 +1,257 @@
+package shortcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tag is an opening or closing tag of shortcode in markdown source.
+type tag struct {
+	start, end  int
+	name        string
+	closing     bool // such as {{< /note >}}
+	selfClosing bool // such as {{< figure src="a.png" />}}, it is never paired
+	escaped     bool // such as {{</* note */>}}
+	raw         string
+	params      map[string]string
+	args        []string
+}
+
+var reName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// scan finds shortcode tags in source, which are not in fenced code blocks or code spans.
+func scan(source []byte) ([]*tag, error) {
+	var (
+		tags  []*tag
+		fence []byte // the opening fence of code block
+	)
+	for i := 0; i < len(source); {
+		if i == 0 || source[i-1] == '\n' {
+			f, rest := fenceAt(source, i)
+			switch {
+			case fence == nil && f != nil:
+				fence = f
+			case fence != nil && f != nil && f[0] == fence[0] && len(f) >= len(fence) && len(bytes.TrimSpace(rest)) == 0:
+				fence = nil
+			}
+			if f != nil || fence != nil {
+				i = nextLine(source, i)
+				continue
+			}
+		}
+
+		switch {
+		case source[i] == '`':
+			n := runLength(source, i, '`')
+			if end := findCodeSpanEnd(source, i+n, n); end > 0 {
+				i = end
+			} else {
+				i += n
+			}
+		case bytes.HasPrefix(source[i:], []byte("{{<")):
+			t, err := parseTag(source, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", bytes.Count(source[:i], []byte("\n"))+1, err)
+			}
+			tags = append(tags, t)
+			i = t.end
+		default:
+			i++
+		}
+	}
+	return tags, nil
+}
+
+// fenceAt returns the fence of code block at line start pos and the rest of line, nil if not a fence.
+func fenceAt(source []byte, pos int) ([]byte, []byte) {
+	i := pos
+	for i < len(source) && i-pos < 3 && source[i] == ' ' {
+		i++
+	}
+	if i >= len(source) || (source[i] != '`' && source[i] != '~') {
+		return nil, nil
+	}
+	n := runLength(source, i, source[i])
+	if n < 3 {
+		return nil, nil
+	}
+	end := nextLine(source, i)
+	return source[i : i+n], source[i+n : end]
+}
+
+// findCodeSpanEnd returns the end of code span closed by n backticks from pos, -1 if not closed.
+func findCodeSpanEnd(source []byte, pos, n int) int {
+	for i := pos; i < len(source); {
+		if source[i] != '`' {
+			i++
+			continue
+		}
+		m := runLength(source, i, '`')
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return -1
+}
+
+func runLength(source []byte, pos int, c byte) int {
+	n := 0
+	for pos+n < len(source) && source[pos+n] == c {
+		n++
+	}
+	return n
+}
+
+func nextLine(source []byte, pos int) int {
+	if i := bytes.IndexByte(source[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(source)
+}
+
+// parseTag parses the tag starting with "{{<" at pos.
+func parseTag(source []byte, pos int) (*tag, error) {
+	content := source[pos+3:]
+	trimmed := bytes.TrimLeft(content, " \t")
+	if bytes.HasPrefix(trimmed, []byte("/*")) {
+		end := bytes.Index(content, []byte("*/>}}"))
+		if end < 0 {
+			return nil, errors.New("unclosed escaped shortcode")
+		}
+		raw := string(content[:end])
+		raw = strings.Replace(raw, "/*", "", 1)
+		return &tag{start: pos, end: pos + 3 + end + 5, escaped: true, raw: raw}, nil
+	}
+
+	// find ">}}" out of quoted params
+	end, quote := -1, byte(0)
+	for i := 0; i < len(content) && end < 0; i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '\n' && i+1 < len(content) && content[i+1] == '\n':
+			return nil, errors.New("unclosed shortcode")
+		case bytes.HasPrefix(content[i:], []byte(">}}")):
+			end = i
+		}
+	}
+	if end < 0 {
+		return nil, errors.New("unclosed shortcode")
+	}
+
+	t := &tag{start: pos, end: pos + 3 + end + 3, params: make(map[string]string)}
+	tokens, err := tokenize(string(content[:end]))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) > 0 && tokens[len(tokens)-1].value == "/" && !tokens[len(tokens)-1].quoted {
+		t.selfClosing = true
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) > 0 && tokens[0].value == "/" && !tokens[0].quoted {
+		t.closing = true
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || tokens[0].key != "" || tokens[0].quoted {
+		return nil, errors.New("shortcode name is missing")
+	}
+	t.name = tokens[0].value
+	if !t.closing && strings.HasPrefix(t.name, "/") {
+		t.closing, t.name = true, t.name[1:]
+	}
+	if !reName.MatchString(t.name) {
+		return nil, fmt.Errorf("invalid shortcode name: %s", t.name)
+	}
+	if t.closing && len(tokens) > 1 {
+		return nil, fmt.Errorf("shortcode %s: closing tag with params", t.name)
+	}
+	for _, tk := range tokens[1:] {
+		if tk.key != "" {
+			t.params[tk.key] = tk.value
+		} else {
+			t.args = append(t.args, tk.value)
+		}
+	}
+	return t, nil
+}
+
+type token struct {
+	key    string
+	value  string
+	quoted bool
+}
+
+// tokenize splits params such as `youtube id="abc" "positional" title=word`.
+func tokenize(s string) ([]*token, error) {
+	var tokens []*token
+	for i := 0; i < len(s); {
+		if isSpace(s[i]) {
+			i++
+			continue
+		}
+		tk := &token{}
+		if s[i] != '"' && s[i] != '`' {
+			start := i
+			for i < len(s) && !isSpace(s[i]) && s[i] != '=' {
+				i++
+			}
+			word := s[start:i]
+			if i >= len(s) || s[i] != '=' {
+				tk.value = word
+				tokens = append(tokens, tk)
+				continue
+			}
+			tk.key = word
+			i++ // skip "="
+			if i >= len(s) || (s[i] != '"' && s[i] != '`') {
+				start = i
+				for i < len(s) && !isSpace(s[i]) {
+					i++
+				}
+				tk.value = s[start:i]
+				tokens = append(tokens, tk)
+				continue
+			}
+		}
+
+		// quoted value
+		quote, start := s[i], i
+		for i++; i < len(s) && s[i] != quote; i++ {
+			if s[i] == '\\' && quote == '"' {
+				i++
+			}
+		}
+		if i >= len(s) {
+			return nil, errors.New("unclosed quote in params")
+		}
+		i++
+		tk.quoted = true
+		if quote == '`' {
+			tk.value = s[start+1 : i-1]
+		} else {
+			v, err := strconv.Unquote(s[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid param %s: %w", s[start:i], err)
+			}
+			tk.value = v
+		}
+		tokens = append(tokens, tk)
+	}
+	return tokens, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,207 @@
+// Package shortcode expands shortcodes in markdown before it is converted,
+// such as {{< youtube id >}} or paired {{< note >}}markdown{{< /note >}}.
+package shortcode
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// Shortcode is a shortcode in markdown.
+type Shortcode struct {
+	Name   string
+	Params map[string]string // named params, such as id="abc"
+	Args   []string          // positional params
+	Inner  string            // raw content between paired tags, empty if not paired
+	Paired bool
+
+	markdown func(string) (string, error)
+}
+
+// Get returns the named param, or the positional param at index if it is not set.
+// Index less than 0 means no positional param.
+func (sc *Shortcode) Get(name string, index int) string {
+	if v, ok := sc.Params[name]; ok {
+		return v
+	}
+	return sc.Arg(index)
+}
+
+// Arg returns the positional param at index, empty if not set.
+func (sc *Shortcode) Arg(index int) string {
+	if index < 0 || index >= len(sc.Args) {
+		return ""
+	}
+	return sc.Args[index]
+}
+
+// InnerHTML returns the inner content converted from markdown, shortcodes in it are expanded too.
+func (sc *Shortcode) InnerHTML() (template.HTML, error) {
+	s, err := sc.Markdown(sc.Inner)
+	return template.HTML(s), err
+}
+
+// Markdown converts markdown source to html.
+func (sc *Shortcode) Markdown(source string) (string, error) {
+	if sc.markdown == nil || strings.TrimSpace(source) == "" {
+		return "", nil
+	}
+	return sc.markdown(source)
+}
+
+// Func renders a shortcode to html.
+type Func func(sc *Shortcode) (string, error)
+
+// Params is the params of expanding shortcodes.
+type Params struct {
+	Render   Func                         // renders shortcodes
+	Markdown func(string) (string, error) // converts inner content of shortcodes
+}
+
+// Expanded is markdown source with shortcodes replaced by placeholders.
+type Expanded struct {
+	Source       []byte
+	placeholders []string
+	html         []string
+}
+
+// Expand renders shortcodes in source, shortcodes in code blocks and code spans are kept,
+// and escaped shortcodes such as {{</* name */>}} are written as they are without "/* */".
+// The result should be converted to html, and then restored by Restore.
+func Expand(source []byte, params *Params) (*Expanded, error) {
+	tags, err := scan(source)
+	if err != nil {
+		return nil, err
+	}
+	e := &Expanded{}
+	if len(tags) == 0 {
+		e.Source = source
+		return e, nil
+	}
+
+	var (
+		buf  bytes.Buffer
+		last int
+	)
+	for i := 0; i < len(tags); i++ {
+		t := tags[i]
+		buf.Write(source[last:t.start])
+		last = t.end
+		if t.escaped {
+			buf.WriteString("{{<" + t.raw + ">}}")
+			continue
+		}
+		if t.closing {
+			return nil, fmt.Errorf("shortcode %s: closing tag without opening tag", t.name)
+		}
+
+		sc := &Shortcode{Name: t.name, Params: t.params, Args: t.args, markdown: params.Markdown}
+		end := t.end
+		if j := findClosing(tags, i); !t.selfClosing && j > 0 {
+			sc.Paired = true
+			sc.Inner = string(source[t.end:tags[j].start])
+			end = tags[j].end
+			last = end
+			i = j
+		}
+		html, err := params.Render(sc)
+		if err != nil {
+			return nil, fmt.Errorf("shortcode %s: %w", t.name, err)
+		}
+
+		// shortcode in its own lines is a block, it is not in a paragraph
+		placeholder := "PUGOSHORTCODE" + strconv.Itoa(len(e.placeholders)) + "X"
+		if indent, ok := lineIndent(source, t.start); ok && isLineEnd(source, end) {
+			buf.WriteString("\n" + indent + placeholder + "\n")
+		} else {
+			buf.WriteString(placeholder)
+		}
+		e.placeholders = append(e.placeholders, placeholder)
+		e.html = append(e.html, html)
+	}
+	buf.Write(source[last:])
+	e.Source = buf.Bytes()
+	return e, nil
+}
+
+// Restore replaces placeholders in converted html with rendered shortcodes.
+func (e *Expanded) Restore(html []byte) []byte {
+	if len(e.placeholders) == 0 {
+		return html
+	}
+	pairs := make([]string, 0, len(e.placeholders)*4)
+	for i, p := range e.placeholders {
+		pairs = append(pairs, "<p>"+p+"</p>", e.html[i], p, e.html[i])
+	}
+	return []byte(strings.NewReplacer(pairs...).Replace(string(html)))
+}
+
+// BriefIndex returns the end of brief in source at the first sep, or -1 if sep is not found.
+// If sep is in a paired shortcode, the brief ends after the closing tag, so the shortcode is not split.
+func BriefIndex(source, sep []byte) int {
+	idx := bytes.Index(source, sep)
+	if idx < 0 {
+		return -1
+	}
+	tags, err := scan(source)
+	if err != nil {
+		// the error is reported in converting
+		return idx
+	}
+	for i := 0; i < len(tags) && tags[i].start < idx; i++ {
+		t := tags[i]
+		if t.escaped || t.closing || t.selfClosing {
+			continue
+		}
+		if j := findClosing(tags, i); j > 0 {
+			if idx < tags[j].start {
+				return tags[j].end
+			}
+			i = j
+		}
+	}
+	return idx
+}
+
+// findClosing returns the index of closing tag of tags[i], or -1 if it is not paired.
+func findClosing(tags []*tag, i int) int {
+	depth := 0
+	for j := i + 1; j < len(tags); j++ {
+		t := tags[j]
+		if t.escaped || t.selfClosing || t.name != tags[i].name {
+			continue
+		}
+		if !t.closing {
+			depth++
+			continue
+		}
+		if depth == 0 {
+			return j
+		}
+		depth--
+	}
+	return -1
+}
+
+// lineIndent returns the spaces before pos in its line, ok is false if there is other text before pos.
+func lineIndent(source []byte, pos int) (string, bool) {
+	i := pos - 1
+	for ; i >= 0 && source[i] != '\n'; i-- {
+		if source[i] != ' ' && source[i] != '\t' {
+			return "", false
+		}
+	}
+	return string(source[i+1 : pos]), true
+}
+
+func isLineEnd(source []byte, pos int) bool {
+	for i := pos; i < len(source) && source[i] != '\n'; i++ {
+		if source[i] != ' ' && source[i] != '\t' && source[i] != '\r' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package shortcode_test
+
+import (
+	"bytes"
+	"pugo/pkg/core/models"
+	"pugo/pkg/ext/markdown"
+	"pugo/pkg/ext/shortcode"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExpand(t *testing.T) {
+	builtins := shortcode.Builtins()
+	convert := markdown.NewConvertFunc(&markdown.Options{
+		Shortcode: func(sc *shortcode.Shortcode) (string, error) {
+			if sc.Name == "hello" {
+				return "<b>hello " + sc.Get("name", 0) + "</b>", nil
+			}
+			return builtins[sc.Name](sc)
+		},
+	})
+	source := "Say {{< hello name=\"PuGo\" >}} inline.\n" +
+		"{{< youtube dQw4w9WgXcQ >}}\n\n" +
+		"- item\n\n  {{< note title=\"Tip\" >}}\n  Use **markdown** {{< hello world />}}.\n  {{< /note >}}\n\n" +
+		"{{< raw-html >}}\n<div id=\"raw\">\n\n</div>\n{{< /raw-html >}}\n\n" +
+		"{{< figure src=\"/a b.png\" alt=\"[A]\" caption=\"<Caption>\" >}}\n\n" +
+		"`{{< hello code >}}` and {{</* hello escaped */>}}\n\n" +
+		"```\n{{< hello fenced >}}\n```\n"
+
+	var buf bytes.Buffer
+	if _, err := convert([]byte(source), &buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"<p>Say <b>hello PuGo</b> inline.</p>",
+		`<div class="video"><iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`,
+		`<div class="admonition note"><p class="admonition-title">Tip</p><p>Use <strong>markdown</strong> <b>hello world</b>.</p>`,
+		"<div id=\"raw\">\n\n</div>",
+		`<figure><img src="/a%20b.png" alt="[A]" /><figcaption>&lt;Caption&gt;</figcaption></figure>`,
+		"<code>{{&lt; hello code &gt;}}</code> and {{&lt; hello escaped &gt;}}",
+		"<code>{{&lt; hello fenced &gt;}}\n</code>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %s\nin %s", want, html)
+		}
+	}
+	if strings.Contains(html, "PUGOSHORTCODE") {
+		t.Errorf("placeholder is left in %s", html)
+	}
+
+	for _, bad := range []string{"{{< youtube >}}", "{{< unclosed", "{{< /note >}}", "{{< hello \"x >}}"} {
+		if _, err := convert([]byte(bad), &buf); err == nil {
+			t.Errorf("expect error of %s", bad)
+		}
+	}
+}
+
+func TestBriefInShortcode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"post.md": {Data: []byte("---\ntitle: Brief\ndate: 2022-02-01\n---\nintro\n\n" +
+			"{{< note >}}\nfirst\n\n<!--more-->\n\nsecond\n{{< /note >}}\n\nrest\n")},
+	}
+	p, err := models.NewPostFromFile(fsys, "post.md", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtins := shortcode.Builtins()
+	convert := markdown.NewConvertFunc(&markdown.Options{
+		Shortcode: func(sc *shortcode.Shortcode) (string, error) {
+			return builtins[sc.Name](sc)
+		},
+	})
+	if err := p.Convert(convert); err != nil {
+		t.Fatal(err)
+	}
+	brief := p.Brief()
+	if !strings.Contains(brief, "<p>second</p>\n</div>") || strings.Contains(brief, "rest") {
+		t.Fatalf("brief should end after the shortcode: %s", brief)
+	}
+	if !strings.Contains(p.Content(), "<p>rest</p>") {
+		t.Fatalf("content is not converted: %s", p.Content())
+	}
+}
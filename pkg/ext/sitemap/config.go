@@ -1,13 +1,39 @@
 package sitemap
 
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxURLs is the max urls in one sitemap file by protocol.
+const DefaultMaxURLs = 50000
+
 type Config struct {
 	Enabled bool   `toml:"enabled"`
 	Link    string `toml:"link"`
+	MaxURLs int    `toml:"max_urls"` // split to files with sitemap index if more urls
+	Images  bool   `toml:"images"`   // add images in posts and pages
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		Enabled: true,
 		Link:    "/sitemap.xml",
+		MaxURLs: DefaultMaxURLs,
 	}
 }
+
+// GetMaxURLs returns the max urls in one sitemap file.
+func (c *Config) GetMaxURLs() int {
+	if c.MaxURLs <= 0 || c.MaxURLs > DefaultMaxURLs {
+		return DefaultMaxURLs
+	}
+	return c.MaxURLs
+}
+
+// FileLink returns the link of n-th split sitemap file, such as "/sitemap-1.xml".
+func (c *Config) FileLink(n int) string {
+	ext := path.Ext(c.Link)
+	return strings.TrimSuffix(c.Link, ext) + "-" + strconv.Itoa(n) + ext
+}
@@ -25,11 +25,13 @@ package sitemap
 import (
 	"bytes"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"path/filepath"
 	"pugo/pkg/core/models"
-	"pugo/pkg/utils/zlog"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -50,6 +52,15 @@ const (
 	Never   ChangeFreq = "never"
 )
 
+// Valid returns true if the change frequency is defined by protocol.
+func (f ChangeFreq) Valid() bool {
+	switch f {
+	case Always, Hourly, Daily, Weekly, Monthly, Yearly, Never:
+		return true
+	}
+	return false
+}
+
 // URL entry in sitemap or sitemap index. LastMod is a pointer
 // to time.Time because omitempty does not work otherwise. Loc is the
 // only mandatory item. ChangeFreq and Priority must be left empty when
@@ -59,6 +70,26 @@ type URL struct {
 	LastMod    *time.Time `xml:"lastmod,omitempty"`
 	ChangeFreq ChangeFreq `xml:"changefreq,omitempty"`
 	Priority   float32    `xml:"priority,omitempty"`
+
+	Alternates []*AlternateLink `xml:"xhtml:link,omitempty"`
+	Images     []*Image         `xml:"image:image,omitempty"`
+}
+
+// Image is an image found in the page.
+type Image struct {
+	Loc string `xml:"image:loc"`
+}
+
+// AlternateLink is the link to the same page in another language.
+type AlternateLink struct {
+	Rel      string `xml:"rel,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// NewAlternateLink returns an alternate link of language.
+func NewAlternateLink(lang, href string) *AlternateLink {
+	return &AlternateLink{Rel: "alternate", HrefLang: lang, Href: href}
 }
 
 // Sitemap represents a complete sitemap which can be marshaled to XML.
@@ -66,39 +97,30 @@ type URL struct {
 // attribute correctly. Minify can be set to make the output less human
 // readable.
 type Sitemap struct {
-	XMLName xml.Name `xml:"urlset"`
-	Xmlns   string   `xml:"xmlns,attr"`
-	URLs    []*URL   `xml:"url"`
-	baseURL string
+	XMLName    xml.Name `xml:"urlset"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	XmlnsXhtml string   `xml:"xmlns:xhtml,attr,omitempty"`
+	XmlnsImage string   `xml:"xmlns:image,attr,omitempty"`
+	URLs       []*URL   `xml:"url"`
+	baseURL    string
+	lock       sync.Mutex
 }
 
-var (
-	globalSiteMap *Sitemap = nil
+const (
+	// MaxFileSize is the max size of one sitemap file, 50MB by protocol.
+	MaxFileSize = 50 * 1024 * 1024
+	headerSize  = 512 // size of xml header and urlset element
 )
 
-// Init initializes the global sitemap.
-func Init(cfg *Config, baseURL string) {
-	if cfg != nil && cfg.Enabled {
-		globalSiteMap = NewSiteMap(baseURL)
-		return
-	}
-	globalSiteMap = nil
-	return
-}
-
-// Add adds an URL to the global sitemap.
-func Add(u *URL) {
-	if globalSiteMap != nil {
-		globalSiteMap.Add(u)
-	}
-}
+var imgSrcRegexp = regexp.MustCompile(`<img[^>]+src="([^"]*)"`)
 
-// Write writes XML encoded sitemap to given io.Writer.
-func Write(w io.Writer) error {
-	if globalSiteMap == nil {
+// New returns a new sitemap of config, it returns nil if sitemap is disabled.
+// Methods of nil sitemap do nothing.
+func New(cfg *Config, baseURL string) *Sitemap {
+	if cfg == nil || !cfg.Enabled {
 		return nil
 	}
-	return globalSiteMap.Write(w)
+	return NewSiteMap(baseURL)
 }
 
 // NewSiteMap returns a new Sitemap.
@@ -110,42 +132,161 @@ func NewSiteMap(baseURL string) *Sitemap {
 }
 
 func (s *Sitemap) fullLoc(loc string) string {
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		return loc
+	}
+	if strings.HasPrefix(loc, "//") {
+		return "https:" + loc
+	}
 	return strings.TrimSuffix(s.baseURL, "/") + "/" + strings.TrimPrefix(loc, "/")
 }
 
 // Add adds an URL to a Sitemap.
 func (s *Sitemap) Add(u *URL) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	u.Loc = s.fullLoc(u.Loc)
+	for _, a := range u.Alternates {
+		a.Href = s.fullLoc(a.Href)
+	}
+	for _, img := range u.Images {
+		img.Loc = s.fullLoc(img.Loc)
+	}
 	s.URLs = append(s.URLs, u)
 }
 
 // WriteTo writes XML encoded sitemap to given io.Writer.
 func (s *Sitemap) Write(w io.Writer) error {
-	_, err := w.Write([]byte(xml.Header))
-	if err != nil {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return writeXML(w, newURLSet(s.URLs))
+}
+
+// newURLSet returns a sitemap of urls with namespaces of extensions in use.
+func newURLSet(urls []*URL) *Sitemap {
+	s := NewSiteMap("")
+	s.URLs = urls
+	for _, u := range urls {
+		if len(u.Alternates) > 0 {
+			s.XmlnsXhtml = "http://www.w3.org/1999/xhtml"
+		}
+		if len(u.Images) > 0 {
+			s.XmlnsImage = "http://www.google.com/schemas/sitemap-image/1.1"
+		}
+	}
+	return s
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
 		return err
 	}
-	en := xml.NewEncoder(w)
-	if err = en.Encode(s); err != nil {
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
 		return err
 	}
-	_, err = w.Write([]byte{'\n'})
-	return nil
+	_, err := w.Write([]byte{'\n'})
+	return err
 }
 
-// Render renders the sitemap to a string.
-func Render(cfg *Config, outputDir string) (*models.OutputFile, error) {
-	if cfg == nil || !cfg.Enabled {
+// split splits urls to groups, each group has max urls and is smaller than max bytes.
+func (s *Sitemap) split(maxURLs, maxBytes int) ([][]*URL, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var (
+		groups [][]*URL
+		group  []*URL
+		size   = headerSize
+	)
+	for _, u := range s.URLs {
+		data, err := xml.Marshal(u)
+		if err != nil {
+			return nil, err
+		}
+		if len(group) > 0 && (len(group) >= maxURLs || size+len(data) > maxBytes) {
+			groups = append(groups, group)
+			group, size = nil, headerSize
+		}
+		group = append(group, u)
+		size += len(data)
+	}
+	if len(group) > 0 || len(groups) == 0 {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Render renders the sitemap,
+// it is split to several files with a sitemap index if there are too many urls.
+func (s *Sitemap) Render(cfg *Config, outputDir string) ([]*models.OutputFile, error) {
+	if s == nil || cfg == nil || !cfg.Enabled {
 		return nil, nil
 	}
+	groups, err := s.split(cfg.GetMaxURLs(), MaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sitemap: %w", err)
+	}
+
+	// one file without index
+	if len(groups) == 1 {
+		buf := bytes.NewBuffer(nil)
+		if err := writeXML(buf, newURLSet(groups[0])); err != nil {
+			return nil, fmt.Errorf("failed to marshal sitemap: %w", err)
+		}
+		return []*models.OutputFile{{Path: filepath.Join(outputDir, cfg.Link), Link: cfg.Link, Buf: buf}}, nil
+	}
+
+	var (
+		outputs []*models.OutputFile
+		index   = &Index{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	)
+	for i, group := range groups {
+		link := cfg.FileLink(i + 1)
+		buf := bytes.NewBuffer(nil)
+		if err := writeXML(buf, newURLSet(group)); err != nil {
+			return nil, fmt.Errorf("failed to marshal sitemap %s: %w", link, err)
+		}
+		outputs = append(outputs, &models.OutputFile{Path: filepath.Join(outputDir, link), Link: link, Buf: buf})
+		index.Sitemaps = append(index.Sitemaps, &URL{Loc: s.fullLoc(link), LastMod: latestLastMod(group)})
+	}
 	buf := bytes.NewBuffer(nil)
-	if err := Write(buf); err != nil {
-		zlog.Warnf("failed to marshal sitemap: %s", err)
-		return nil, err
-	}
-	dstFile := filepath.Join(outputDir, cfg.Link)
-	return &models.OutputFile{
-		Path: dstFile,
-		Buf:  buf,
-	}, nil
+	if err := writeXML(buf, index); err != nil {
+		return nil, fmt.Errorf("failed to marshal sitemap index: %w", err)
+	}
+	outputs = append(outputs, &models.OutputFile{Path: filepath.Join(outputDir, cfg.Link), Link: cfg.Link, Buf: buf})
+	return outputs, nil
+}
+
+// Index is the sitemap index of split sitemap files.
+type Index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Sitemaps []*URL   `xml:"sitemap"`
+}
+
+func latestLastMod(urls []*URL) *time.Time {
+	var t *time.Time
+	for _, u := range urls {
+		if u.LastMod != nil && (t == nil || u.LastMod.After(*t)) {
+			t = u.LastMod
+		}
+	}
+	return t
+}
+
+// ImagesFromHTML returns images in html content.
+func ImagesFromHTML(html string) []*Image {
+	var images []*Image
+	seen := make(map[string]bool)
+	for _, m := range imgSrcRegexp.FindAllStringSubmatch(html, -1) {
+		src := m[1]
+		if src == "" || seen[src] || strings.HasPrefix(src, "data:") {
+			continue
+		}
+		seen[src] = true
+		images = append(images, &Image{Loc: src})
+	}
+	return images
 }
@@ -0,0 +1,16 @@
+package toc
+
+// Config is the config of table of contents in posts and pages.
+type Config struct {
+	Enabled  bool `toml:"enabled"`   // build table of contents, it is disabled in a post by "toc = false" in front-matter
+	MinDepth int  `toml:"min_depth"` // minimum level of headings in table of contents
+	MaxDepth int  `toml:"max_depth"` // maximum level of headings in table of contents
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:  true,
+		MinDepth: 2,
+		MaxDepth: 4,
+	}
+}
@@ -0,0 +1,69 @@
+// Package toc builds table of contents from headings of markdown.
+package toc
+
+import (
+	"html"
+	"html/template"
+	"pugo/pkg/ext/markdown"
+	"strings"
+)
+
+// Item is a heading in table of contents.
+type Item struct {
+	Level    int
+	ID       string
+	Text     string
+	Children []*Item
+}
+
+// TOC is the table of contents of a post or page.
+type TOC struct {
+	Items []*Item
+	HTML  template.HTML // nested lists of links to headings
+}
+
+// Build returns table of contents of headings in depth of cfg, or nil if no heading is in it.
+// Headings without id are skipped as they can not be linked.
+func Build(headings []*markdown.Heading, cfg *Config) *TOC {
+	var (
+		items []*Item
+		stack []*Item
+	)
+	for _, h := range headings {
+		if h.Level < cfg.MinDepth || h.Level > cfg.MaxDepth || h.ID == "" {
+			continue
+		}
+		item := &Item{Level: h.Level, ID: h.ID, Text: h.Text}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			items = append(items, item)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	var buf strings.Builder
+	buf.WriteString(`<nav class="toc">`)
+	writeList(&buf, items)
+	buf.WriteString(`</nav>`)
+	return &TOC{Items: items, HTML: template.HTML(buf.String())}
+}
+
+func writeList(buf *strings.Builder, items []*Item) {
+	buf.WriteString("<ul>")
+	for _, item := range items {
+		buf.WriteString(`<li><a href="#` + html.EscapeString(item.ID) + `">` + html.EscapeString(item.Text) + "</a>")
+		if len(item.Children) > 0 {
+			writeList(buf, item.Children)
+		}
+		buf.WriteString("</li>")
+	}
+	buf.WriteString("</ul>")
+}
@@ -0,0 +1,30 @@
+package toc
+
+import (
+	"bytes"
+	"pugo/pkg/ext/markdown"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	converter := markdown.NewConvertFunc(nil)
+	var buf bytes.Buffer
+	headings, err := converter([]byte("# Title\n\n### Orphan\n\n## A & B\n\n### A1\n\n#### Deep\n\n##### Too deep\n\n## C\n"), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toc := Build(headings, DefaultConfig())
+	want := `<nav class="toc"><ul><li><a href="#orphan">Orphan</a></li>` +
+		`<li><a href="#a--b">A &amp; B</a><ul><li><a href="#a1">A1</a><ul><li><a href="#deep">Deep</a></li></ul></li></ul></li>` +
+		`<li><a href="#c">C</a></li></ul></nav>`
+	if string(toc.HTML) != want {
+		t.Errorf("html = %s\nwant %s", toc.HTML, want)
+	}
+	if len(toc.Items) != 3 || toc.Items[1].Children[0].Children[0].Level != 4 {
+		t.Errorf("unexpected items: %+v", toc.Items)
+	}
+	if Build(headings[:1], DefaultConfig()) != nil {
+		t.Error("toc without headings in depth should be nil")
+	}
+}
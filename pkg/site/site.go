@@ -0,0 +1,165 @@
+// Package site builds a PuGo site from a directory or a filesystem,
+// it is used to embed PuGo in other programs.
+package site
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"pugo/pkg/core/generator"
+	"pugo/pkg/core/output"
+	"pugo/pkg/core/report"
+	"pugo/pkg/utils/zlog"
+	"sort"
+	"time"
+)
+
+// Options is the options for building a site.
+type Options struct {
+	OutputDir     string      // relative to site root, use build config if empty
+	Drafts        bool        // render drafts
+	Future        bool        // render posts dated in the future
+	Now           time.Time   // time to decide scheduled and expired posts, use current time if zero
+	Jobs          int         // number of parallel render workers, use cpu number if zero
+	DisableCache  bool        // ignore the manifest of last build and rebuild all files
+	ValidateFeed  bool        // check generated feeds and fail the build if invalid
+	Strict        bool        // fail the build on warnings in diagnostics
+	CheckLinks    bool        // check links, anchors and images in generated html, build cache is not used
+	CheckExternal bool        // also request external links in checking links
+	Logger        zlog.Logger // receives logs of builds, discard logs if nil
+
+	// Output writes built files instead of output directory, such as output.NewMemory(),
+	// build cache is disabled with it
+	Output output.Writer
+}
+
+// Site is a site to build.
+// Builds of one site should not run at the same time, they write the same output directory.
+type Site struct {
+	root string
+	fsys fs.FS
+	opts Options
+}
+
+// New returns the site in root directory.
+func New(root string, opts *Options) *Site {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	s := &Site{root: root}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// NewFS returns the site reading config, contents and theme from fsys,
+// such as an fstest.MapFS, a zip.Reader or a git tree exposed as fs.FS.
+// Outputs are written in current directory unless Options.Output is set.
+// The site has no root directory to keep build cache, so all files are rebuilt in each build,
+// and stale files of last build are not removed.
+func NewFS(fsys fs.FS, opts *Options) *Site {
+	s := &Site{fsys: fsys}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// Timings is the duration of each stage of a build.
+type Timings = generator.Timings
+
+// Output is a file in output directory.
+// Link and Data are empty for static assets and outputs skipped by build cache.
+type Output struct {
+	Path    string // relative to output directory
+	Link    string
+	Data    []byte // rendered content before minifying
+	Changed bool   // written in this build
+}
+
+// Result is the result of a build.
+type Result struct {
+	OutputDir string
+	Outputs   []*Output // sorted by path
+	Timings   Timings
+
+	// Diagnostics are problems of config, contents and templates found in the build
+	Diagnostics []*report.Diagnostic
+}
+
+// Build builds the site, it stops with the error of ctx when ctx is canceled.
+// The result is returned with warnings and diagnostics even if the build fails,
+// outputs are also in the result if the build fails with errors in diagnostics.
+func (s *Site) Build(ctx context.Context) (*Result, error) {
+	log := s.opts.Logger
+	if log == nil {
+		log = zlog.Nop()
+	}
+	opt := &generator.Option{
+		RootDir:       s.root,
+		SourceFS:      s.fsys,
+		OutputDir:     s.opts.OutputDir,
+		EnableDrafts:  s.opts.Drafts,
+		EnableFuture:  s.opts.Future,
+		DisableCache:  s.opts.DisableCache,
+		Jobs:          s.opts.Jobs,
+		ValidateFeed:  s.opts.ValidateFeed,
+		Strict:        s.opts.Strict,
+		CheckLinks:    s.opts.CheckLinks,
+		CheckExternal: s.opts.CheckExternal,
+		Logger:        log,
+		Output:        s.opts.Output,
+	}
+	if !s.opts.Now.IsZero() {
+		now := s.opts.Now
+		opt.Clock = func() time.Time { return now }
+	}
+
+	res, err := generator.Build(ctx, opt)
+	result := &Result{
+		Diagnostics: res.Report.Diagnostics(),
+	}
+	if err != nil && !errors.Is(err, report.ErrFailed) {
+		return result, err
+	}
+	result.OutputDir = res.OutputDir
+	result.Timings = res.Timings
+	outputs, outErr := newOutputs(res)
+	if outErr != nil {
+		return result, outErr
+	}
+	result.Outputs = outputs
+	return result, err
+}
+
+func newOutputs(res *generator.Result) ([]*Output, error) {
+	rendered := make(map[string]*Output, len(res.Outputs))
+	for _, o := range res.Outputs {
+		rendered[o.Path] = &Output{Link: o.Link, Data: o.Buf.Bytes()}
+	}
+	changed := make(map[string]bool, len(res.ChangedFiles))
+	for _, file := range res.ChangedFiles {
+		changed[file] = true
+	}
+	outputs := make([]*Output, 0, len(res.Files))
+	for _, file := range res.Files {
+		out := rendered[file]
+		if out == nil {
+			out = &Output{}
+		}
+		rel, err := filepath.Rel(res.OutputDir, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relative path: %s, %w", file, err)
+		}
+		out.Path = filepath.ToSlash(rel)
+		out.Changed = changed[file]
+		outputs = append(outputs, out)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Path < outputs[j].Path
+	})
+	return outputs, nil
+}
@@ -0,0 +1,121 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"pugo/pkg/core/output"
+	"pugo/pkg/core/report"
+	"pugo/themes"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// memorySite returns a site with default theme, all files are in memory.
+func memorySite(t *testing.T) fstest.MapFS {
+	files := fstest.MapFS{
+		"config.toml": {Data: []byte(`
+[site]
+  title = "Memory"
+  base = "https://example.com"
+`)},
+		"content/posts/hello.md": {Data: []byte(`---
+title: Hello
+slug: hello
+date: 2022-02-01 10:00:00
+tags: [go]
+---
+hello **memory**
+`)},
+		"content/pages/about.md": {Data: []byte(`---
+title: About
+slug: about
+---
+about page
+`)},
+	}
+	err := fs.WalkDir(themes.DefaultAssets, "default", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := themes.DefaultAssets.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[path.Join("themes", file)] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestBuildInMemory(t *testing.T) {
+	out := output.NewMemory()
+	s := NewFS(memorySite(t), &Options{Output: out})
+	res, err := s.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", res.Diagnostics)
+	}
+
+	for _, name := range []string{"index.html", "2022/02/hello/index.html", "about/index.html", "atom.xml", "sitemap.xml"} {
+		data, err := out.ReadFile(name)
+		if err != nil {
+			t.Fatalf("output %s is missing: %s", name, err)
+		}
+		if len(data) == 0 {
+			t.Fatalf("output %s is empty", name)
+		}
+	}
+	data, _ := out.ReadFile("2022/02/hello/index.html")
+	if !strings.Contains(string(data), "<strong>memory</strong>") {
+		t.Fatal("post content is not rendered")
+	}
+	if len(res.Outputs) != len(out.Files()) {
+		t.Fatalf("result has %d outputs, but %d files written", len(res.Outputs), len(out.Files()))
+	}
+	if _, err := os.Stat("build"); !os.IsNotExist(err) {
+		t.Fatal("outputs should not be written to local directory")
+	}
+}
+
+func TestBuildCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewFS(memorySite(t), &Options{Output: output.NewMemory()})
+	if _, err := s.Build(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("build should be canceled, got: %v", err)
+	}
+}
+
+func TestBuildDiagnostics(t *testing.T) {
+	files := memorySite(t)
+	files["content/posts/broken.md"] = &fstest.MapFile{Data: []byte(`---
+title: Broken
+date: 2022-13-45
+---
+broken
+`)}
+	out := output.NewMemory()
+	res, err := NewFS(files, &Options{Output: out}).Build(context.Background())
+	if !errors.Is(err, report.ErrFailed) {
+		t.Fatalf("build should fail with content errors, got: %v", err)
+	}
+	if len(res.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got: %v", res.Diagnostics)
+	}
+	d := res.Diagnostics[0]
+	if d.Severity != report.SeverityError || d.File != "content/posts/broken.md" || d.Line != 3 {
+		t.Fatalf("unexpected diagnostic: %s", d)
+	}
+	if _, err := out.ReadFile("2022/02/hello/index.html"); err != nil {
+		t.Fatalf("other outputs should be written: %s", err)
+	}
+}
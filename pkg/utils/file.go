@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -45,7 +47,20 @@ func CopyFile(src, dst string) error {
 		return err
 	}
 	defer r.Close()
+	return writeFrom(dst, r)
+}
 
+// CopyFSFile copies a file in fsys to dst
+func CopyFSFile(fsys fs.FS, src, dst string) error {
+	r, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return writeFrom(dst, r)
+}
+
+func writeFrom(dst string, r io.Reader) error {
 	// create subdir if necessary
 	dir := filepath.Dir(dst)
 	if !IsDirExist(dir) {
@@ -63,12 +78,11 @@ func CopyFile(src, dst string) error {
 
 	w, err := os.Create(dst)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer w.Close()
-	w.ReadFrom(r)
-
-	return nil
+	_, err = w.ReadFrom(r)
+	return err
 }
 
 // IsTempFile checks if a file is a temporary file
@@ -0,0 +1,42 @@
+package zlog
+
+// Logger is the logger used in building a site,
+// it can be replaced to collect or discard the logs of one build.
+type Logger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Warnf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Debugf(template string, args ...interface{}) { Debugf(template, args...) }
+func (stdLogger) Infof(template string, args ...interface{})  { Infof(template, args...) }
+func (stdLogger) Warnf(template string, args ...interface{})  { Warnf(template, args...) }
+func (stdLogger) Errorf(template string, args ...interface{}) { Errorf(template, args...) }
+
+// Std returns the logger writing to the global logger.
+func Std() Logger {
+	return stdLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(template string, args ...interface{}) {}
+func (nopLogger) Infof(template string, args ...interface{})  {}
+func (nopLogger) Warnf(template string, args ...interface{})  {}
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// Nop returns the logger discarding all logs.
+func Nop() Logger {
+	return nopLogger{}
+}
+
+// OrStd returns l, or the global logger if l is nil.
+func OrStd(l Logger) Logger {
+	if l == nil {
+		return Std()
+	}
+	return l
+}
@@ -2,6 +2,7 @@ package zlog
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -13,11 +14,12 @@ var (
 	_sugaredLogger *zap.SugaredLogger
 	logTmFmtWithMS = "2006/01/02 15:04:05"
 	_debug         bool
+	_output        io.Writer = os.Stdout
 )
 
 func Init(debug bool) {
 	_debug = debug
-	writer := zapcore.AddSync(os.Stdout)
+	writer := zapcore.AddSync(_output)
 
 	encoderConfig := zap.NewProductionEncoderConfig()
 
@@ -37,6 +39,12 @@ func Init(debug bool) {
 	_sugaredLogger = logger.Sugar()
 }
 
+// SetOutput sets the writer of logs, default is stdout.
+func SetOutput(w io.Writer) {
+	_output = w
+	Init(_debug)
+}
+
 func loadZap() {
 	if _sugaredLogger == nil {
 		Init(false)
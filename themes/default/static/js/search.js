@@ -0,0 +1,59 @@
+// client-side search by generated search index
+(function () {
+    var box = document.getElementById("search");
+    if (!box) {
+        return;
+    }
+    var input = document.getElementById("search-input"),
+        results = document.getElementById("search-results"),
+        entries = null;
+
+    function fetchJSON(link) {
+        return fetch(link).then(function (resp) { return resp.json(); });
+    }
+
+    // load index and its shards once
+    function load() {
+        if (entries) {
+            return Promise.resolve(entries);
+        }
+        return fetchJSON(box.dataset.index).then(function (index) {
+            if (!index.shards) {
+                return index.entries || [];
+            }
+            return Promise.all(index.shards.map(fetchJSON)).then(function (shards) {
+                return shards.reduce(function (all, s) { return all.concat(s.entries || []); }, []);
+            });
+        }).then(function (all) {
+            entries = all;
+            return entries;
+        });
+    }
+
+    function render(items) {
+        results.innerHTML = "";
+        items.slice(0, 10).forEach(function (e) {
+            var li = document.createElement("li"), a = document.createElement("a");
+            li.className = "search-item";
+            a.href = e.link;
+            a.textContent = e.title;
+            li.appendChild(a);
+            results.appendChild(li);
+        });
+    }
+
+    input.addEventListener("input", function () {
+        var q = input.value.trim().toLowerCase();
+        if (!q) {
+            render([]);
+            return;
+        }
+        load().then(function (all) {
+            render(all.filter(function (e) {
+                return e.title.toLowerCase().indexOf(q) >= 0 ||
+                    e.content.toLowerCase().indexOf(q) >= 0 ||
+                    (e.tags || []).some(function (t) { return t.toLowerCase().indexOf(q) >= 0; });
+            }));
+        });
+    });
+})();